@@ -0,0 +1,253 @@
+package ssdb
+
+import (
+	"math/bits"
+	"time"
+)
+
+// Bitmap is the raw bytes of a kv value used as a bit array, fetched by BitmapStore.Fetch.
+// Like setbit and getbit of ssdb, bit i is bit i%8, from the least significant, of byte i/8.
+type Bitmap []byte
+
+// Test reports whether bit i is set.
+func (bm Bitmap) Test(i int) bool {
+	return i >= 0 && i/8 < len(bm) && bm[i/8]&(1<<uint(i%8)) != 0
+}
+
+// Count returns the number of bits set.
+func (bm Bitmap) Count() int64 {
+	var n int
+	for _, b := range bm {
+		n += bits.OnesCount8(b)
+	}
+	return int64(n)
+}
+
+// Bits returns the offsets of the bits set, in ascending order.
+func (bm Bitmap) Bits() []int {
+	var offsets []int
+	for i, b := range bm {
+		for b != 0 {
+			j := bits.TrailingZeros8(b)
+			offsets = append(offsets, i*8+j)
+			b &^= 1 << uint(j)
+		}
+	}
+	return offsets
+}
+
+// And returns the bits set in both of bm and o.
+func (bm Bitmap) And(o Bitmap) Bitmap {
+	res := make(Bitmap, min(len(bm), len(o)))
+	for i := range res {
+		res[i] = bm[i] & o[i]
+	}
+	return res
+}
+
+// Or returns the bits set in either of bm and o.
+func (bm Bitmap) Or(o Bitmap) Bitmap {
+	res := make(Bitmap, max(len(bm), len(o)))
+	copy(res, bm)
+	for i, b := range o {
+		res[i] |= b
+	}
+	return res
+}
+
+// Xor returns the bits set in only one of bm and o.
+func (bm Bitmap) Xor(o Bitmap) Bitmap {
+	res := make(Bitmap, max(len(bm), len(o)))
+	copy(res, bm)
+	for i, b := range o {
+		res[i] ^= b
+	}
+	return res
+}
+
+// AndNot returns the bits set in bm but not in o.
+func (bm Bitmap) AndNot(o Bitmap) Bitmap {
+	res := make(Bitmap, len(bm))
+	copy(res, bm)
+	for i := range min(len(bm), len(o)) {
+		res[i] &^= o[i]
+	}
+	return res
+}
+
+// Not returns the bits not set in the first n bits of bm, bm is taken as zero-padded to n bits.
+func (bm Bitmap) Not(n int) Bitmap {
+	res := make(Bitmap, (n+7)/8)
+	copy(res, bm)
+	for i := range res {
+		res[i] = ^res[i]
+	}
+	if n%8 != 0 {
+		res[len(res)-1] &= 1<<uint(n%8) - 1
+	}
+	return res
+}
+
+// BitmapStore records events of users in bitmaps of days, the bit of a user is the user id,
+// set by setbit on the key prefix+event+":"+day, like "prefix:login:20240131".
+// The bitmaps are combined in the client, so daily, weekly and monthly active users,
+// retention and cohorts are counted without scanning the users.
+// Caution: the bitmaps are raw values, don't enable compression on the Client used.
+type BitmapStore struct {
+	r      Runner
+	prefix string
+	loc    *time.Location
+}
+
+// NewBitmapStore returns a BitmapStore on a Client or a Pool, the days start in loc, nil for UTC.
+func NewBitmapStore(r Runner, prefix string, loc *time.Location) *BitmapStore {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &BitmapStore{r: r, prefix: prefix, loc: loc}
+}
+
+// Key returns the key of the bitmap of event on the day including t.
+func (s *BitmapStore) Key(event string, t time.Time) string {
+	return s.prefix + event + ":" + t.In(s.loc).Format("20060102")
+}
+
+// Mark records the event of the user on the day including t.
+func (s *BitmapStore) Mark(event string, user int32, t time.Time) error {
+	return s.r.Run(func(c *Client) error {
+		_, err := c.Setbit(s.Key(event, t), user, 1)
+		return err
+	})
+}
+
+// Marked reports whether the user has the event on the day including t.
+func (s *BitmapStore) Marked(event string, user int32, t time.Time) (bool, error) {
+	var n int64
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Getbit(s.Key(event, t), user)
+		return err
+	})
+	return n == 1, err
+}
+
+// Count returns the number of users having the event on the day including t, like DAU.
+func (s *BitmapStore) Count(event string, t time.Time) (int64, error) {
+	var n int64
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Countbit(s.Key(event, t))
+		if err != nil && err.Error() == "not_found" {
+			err = nil
+		}
+		return err
+	})
+	return n, err
+}
+
+// CountDays returns the number of users having the event in the days days ending on the day
+// including t, like WAU for 7 days and MAU for 30 days.
+func (s *BitmapStore) CountDays(event string, t time.Time, days int) (int64, error) {
+	bm, err := s.Days(event, t, days)
+	if err != nil {
+		return 0, err
+	}
+	return bm.Count(), nil
+}
+
+// DAU returns the number of daily active users on the day including t.
+func (s *BitmapStore) DAU(event string, t time.Time) (int64, error) {
+	return s.Count(event, t)
+}
+
+// WAU returns the number of active users in the 7 days ending on the day including t.
+func (s *BitmapStore) WAU(event string, t time.Time) (int64, error) {
+	return s.CountDays(event, t, 7)
+}
+
+// MAU returns the number of active users in the 30 days ending on the day including t.
+func (s *BitmapStore) MAU(event string, t time.Time) (int64, error) {
+	return s.CountDays(event, t, 30)
+}
+
+// Days returns the users having the event in the days days ending on the day including t.
+func (s *BitmapStore) Days(event string, t time.Time, days int) (Bitmap, error) {
+	keys := make([]string, days)
+	for i := range keys {
+		keys[i] = s.Key(event, t.AddDate(0, 0, -i))
+	}
+	bms, err := s.Fetch(keys...)
+	if err != nil {
+		return nil, err
+	}
+	var res Bitmap
+	for _, bm := range bms {
+		res = res.Or(bm)
+	}
+	return res, nil
+}
+
+// Fetch returns the bitmaps of the keys by multi_get, the bitmaps of keys not existed are empty.
+func (s *BitmapStore) Fetch(keys ...string) ([]Bitmap, error) {
+	bms := make([]Bitmap, len(keys))
+	if len(keys) == 0 {
+		return bms, nil
+	}
+	err := s.r.Run(func(c *Client) error {
+		resp, err := c.doReturnRange("multi_get", keys)
+		if err != nil {
+			return err
+		}
+		values := make(map[string]string, len(resp)/2)
+		for i := 0; i+1 < len(resp); i += 2 {
+			values[resp[i]] = resp[i+1]
+		}
+		for i, key := range keys {
+			bms[i] = Bitmap(values[key])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bms, nil
+}
+
+// Store saves the bitmap as the value of key, which expires after ttl seconds if ttl is positive.
+// The bitmaps stored are read by getbit, countbit, and Fetch like the bitmaps of days.
+func (s *BitmapStore) Store(key string, bm Bitmap, ttl int64) error {
+	return s.r.Run(func(c *Client) error {
+		if ttl > 0 {
+			return c.doReturn("setx", key, []byte(bm), ttl)
+		}
+		return c.doReturn("set", key, []byte(bm))
+	})
+}
+
+// Retention returns the number of users having the event on the day including cohort,
+// and on each of the days days after it. The first item is the size of the cohort.
+func (s *BitmapStore) Retention(event string, cohort time.Time, days int) ([]int64, error) {
+	keys := make([]string, days+1)
+	for i := range keys {
+		keys[i] = s.Key(event, cohort.AddDate(0, 0, i))
+	}
+	bms, err := s.Fetch(keys...)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int64, len(bms))
+	for i, bm := range bms {
+		counts[i] = bms[0].And(bm).Count()
+	}
+	return counts, nil
+}
+
+// Cohort returns the number of users having cohortEvent on the day including cohortDay,
+// who have event on the day including t, like the users signed up on a day and paid on another.
+func (s *BitmapStore) Cohort(cohortEvent string, cohortDay time.Time, event string, t time.Time) (int64, error) {
+	bms, err := s.Fetch(s.Key(cohortEvent, cohortDay), s.Key(event, t))
+	if err != nil {
+		return 0, err
+	}
+	return bms[0].And(bms[1]).Count(), nil
+}
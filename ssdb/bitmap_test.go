@@ -0,0 +1,96 @@
+package ssdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBitmap(t *testing.T) {
+	a := Bitmap{0x05, 0x80} // 0, 2, 15
+	b := Bitmap{0x06}       // 1, 2
+
+	if !a.Test(15) || a.Test(1) || a.Test(16) || a.Test(-1) {
+		t.Fatalf("Test failed\n")
+	}
+	if n := a.Count(); n != 3 {
+		t.Fatalf("Count failed, expected:3, got:%v\n", n)
+	}
+	tests := []struct {
+		name     string
+		bm       Bitmap
+		expected []int
+	}{
+		{"Bits", a, []int{0, 2, 15}},
+		{"And", a.And(b), []int{2}},
+		{"Or", a.Or(b), []int{0, 1, 2, 15}},
+		{"Xor", a.Xor(b), []int{0, 1, 15}},
+		{"AndNot", a.AndNot(b), []int{0, 15}},
+		{"Not", b.Not(5), []int{0, 3, 4}},
+		{"Not", b.Not(10), []int{0, 3, 4, 5, 6, 7, 8, 9}},
+	}
+	for _, tt := range tests {
+		if got := tt.bm.Bits(); !reflect.DeepEqual(got, tt.expected) {
+			t.Fatalf("%s failed, expected:%v, got:%v\n", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestBitmapStore(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewBitmapStore(p, "bitmap_test:", nil)
+	day := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	next := day.AddDate(0, 0, 1)
+	defer func() {
+		c := p.Get()
+		c.MultiDel(s.Key("login", day), s.Key("login", next), "bitmap_test:result")
+		p.Release(c)
+	}()
+
+	for _, user := range []int32{1, 5, 100} {
+		if err := s.Mark("login", user, day); err != nil {
+			t.Fatalf("Mark failed, err:%v\n", err)
+		}
+	}
+	for _, user := range []int32{5, 7} {
+		if err := s.Mark("login", user, next); err != nil {
+			t.Fatalf("Mark failed, err:%v\n", err)
+		}
+	}
+
+	ok, err := s.Marked("login", 100, day)
+	if err != nil || !ok {
+		t.Fatalf("Marked failed, got:%v, err:%v\n", ok, err)
+	}
+	n, err := s.DAU("login", day)
+	if err != nil || n != 3 {
+		t.Fatalf("DAU failed, expected:3, got:%v, err:%v\n", n, err)
+	}
+	n, err = s.WAU("login", next)
+	if err != nil || n != 4 {
+		t.Fatalf("WAU failed, expected:4, got:%v, err:%v\n", n, err)
+	}
+	counts, err := s.Retention("login", day, 1)
+	if err != nil || !reflect.DeepEqual(counts, []int64{3, 1}) {
+		t.Fatalf("Retention failed, expected:[3 1], got:%v, err:%v\n", counts, err)
+	}
+
+	bms, err := s.Fetch(s.Key("login", day), s.Key("login", next))
+	if err != nil {
+		t.Fatalf("Fetch failed, err:%v\n", err)
+	}
+	err = s.Store("bitmap_test:result", bms[0].AndNot(bms[1]), 0)
+	if err != nil {
+		t.Fatalf("Store failed, err:%v\n", err)
+	}
+	c := p.Get()
+	n, err = c.Countbit("bitmap_test:result")
+	p.Release(c)
+	if err != nil || n != 2 {
+		t.Fatalf("Countbit failed, expected:2, got:%v, err:%v\n", n, err)
+	}
+}
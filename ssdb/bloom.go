@@ -0,0 +1,312 @@
+package ssdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strconv"
+)
+
+const (
+	// bloomShardBits is the number of bits kept in a key, a getbit reads the whole key.
+	bloomShardBits = 1 << 16
+	// bloomBatch is the number of commands pipelined at once.
+	bloomBatch = 1000
+	// bloomMaxLayers limits the growth of a BloomFilter.
+	bloomMaxLayers = 32
+)
+
+// BloomFilter is a scalable Bloom filter stored in bits of kv keys, by setbit and getbit.
+// It starts with a layer sized for the capacity and the false positive rate, and adds a layer
+// twice as large, with half the false positive rate, every time the layers are full, so
+// the false positive rate of all the layers stays below the rate given.
+// The bits of layer i are kept in keys name+":"+i+":"+shard of 65536 bits each, and the number
+// of items added is kept in the key name+":count".
+type BloomFilter struct {
+	r      Runner
+	name   string
+	layers []bloomLayer
+}
+
+type bloomLayer struct {
+	// capacity is the number of items the layer holds.
+	capacity int64
+	// bits is the size of the bit array.
+	bits uint64
+	// hashes is the number of hash functions.
+	hashes int
+}
+
+// NewBloomFilter returns a BloomFilter on a Client or a Pool, for capacity items at first
+// with the false positive rate fpRate, like 0.01.
+func NewBloomFilter(r Runner, name string, capacity int64, fpRate float64) (*BloomFilter, error) {
+	if capacity <= 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.New("false positive rate must be in (0, 1)")
+	}
+
+	b := &BloomFilter{r: r, name: name}
+	n, p := capacity, fpRate/2
+	for i := 0; i < bloomMaxLayers; i++ {
+		bits := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+		hashes := int(math.Round(bits / float64(n) * math.Ln2))
+		if hashes < 1 {
+			hashes = 1
+		}
+		b.layers = append(b.layers, bloomLayer{capacity: n, bits: uint64(bits), hashes: hashes})
+		if n > math.MaxInt64/2 {
+			break
+		}
+		n, p = n*2, p/2
+	}
+	return b, nil
+}
+
+// Add adds an item, and reports whether it was not in the filter before.
+func (b *BloomFilter) Add(item string) (bool, error) {
+	added, err := b.AddMulti(item)
+	if err != nil {
+		return false, err
+	}
+	return added[0], nil
+}
+
+// Test reports whether an item may be in the filter.
+func (b *BloomFilter) Test(item string) (bool, error) {
+	found, err := b.TestMulti(item)
+	if err != nil {
+		return false, err
+	}
+	return found[0], nil
+}
+
+// AddMulti adds the items, and reports whether each of them was not in the filter before.
+// The items in the filter already are not added again, so they don't fill the filter.
+func (b *BloomFilter) AddMulti(items ...string) ([]bool, error) {
+	added := make([]bool, len(items))
+	err := b.r.Run(func(c *Client) error {
+		count, err := b.count(c)
+		if err != nil {
+			return err
+		}
+		found, err := b.test(c, count, items)
+		if err != nil {
+			return err
+		}
+
+		var cmds [][]interface{}
+		var n int64
+		seen := make(map[string]bool)
+		for i, item := range items {
+			if found[i] || seen[item] {
+				continue
+			}
+			seen[item] = true
+			added[i] = true
+			layer := b.layer(count + n)
+			for _, offset := range b.offsets(layer, item) {
+				key, bit := b.bit(layer, offset)
+				cmds = append(cmds, []interface{}{"setbit", key, bit, 1})
+			}
+			n++
+		}
+		if n == 0 {
+			return nil
+		}
+		if _, err = b.pipeline(c, cmds); err != nil {
+			return err
+		}
+		_, err = c.Incr(b.name+":count", n)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// TestMulti reports whether each of the items may be in the filter.
+func (b *BloomFilter) TestMulti(items ...string) ([]bool, error) {
+	var found []bool
+	err := b.r.Run(func(c *Client) error {
+		count, err := b.count(c)
+		if err != nil {
+			return err
+		}
+		found, err = b.test(c, count, items)
+		return err
+	})
+	return found, err
+}
+
+// Count returns the number of items added.
+func (b *BloomFilter) Count() (int64, error) {
+	var count int64
+	err := b.r.Run(func(c *Client) error {
+		var err error
+		count, err = b.count(c)
+		return err
+	})
+	return count, err
+}
+
+// FillRatio returns the ratio of the bits set in the layers in use, the false positive rate
+// of a layer is about its fill ratio to the power of its number of hash functions.
+func (b *BloomFilter) FillRatio() (float64, error) {
+	var ratio float64
+	err := b.r.Run(func(c *Client) error {
+		count, err := b.count(c)
+		if err != nil {
+			return err
+		}
+
+		var cmds [][]interface{}
+		var total uint64
+		for i := 0; i <= b.layer(count); i++ {
+			for shard := uint64(0); shard*bloomShardBits < b.layers[i].bits; shard++ {
+				cmds = append(cmds, []interface{}{"countbit", b.key(i, shard)})
+			}
+			total += b.layers[i].bits
+		}
+		resps, err := b.pipeline(c, cmds)
+		if err != nil {
+			return err
+		}
+		var set int64
+		for _, resp := range resps {
+			n, _ := strconv.ParseInt(resp[1], 10, 64)
+			set += n
+		}
+		ratio = float64(set) / float64(total)
+		return nil
+	})
+	return ratio, err
+}
+
+// Clear deletes all the keys of the filter.
+func (b *BloomFilter) Clear() error {
+	return b.r.Run(func(c *Client) error {
+		count, err := b.count(c)
+		if err != nil {
+			return err
+		}
+
+		var cmds [][]interface{}
+		for i := 0; i <= b.layer(count); i++ {
+			for shard := uint64(0); shard*bloomShardBits < b.layers[i].bits; shard++ {
+				cmds = append(cmds, []interface{}{"del", b.key(i, shard)})
+			}
+		}
+		if _, err = b.pipeline(c, cmds); err != nil {
+			return err
+		}
+		return c.Del(b.name + ":count")
+	})
+}
+
+func (b *BloomFilter) count(c *Client) (int64, error) {
+	s, err := c.Get(b.name + ":count")
+	if err != nil {
+		if err.Error() == "not_found" {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// layer returns the layer the item is added to after count items.
+func (b *BloomFilter) layer(count int64) int {
+	for i, l := range b.layers {
+		if count < l.capacity {
+			return i
+		}
+		count -= l.capacity
+	}
+	return len(b.layers) - 1
+}
+
+// test reports whether each of the items is in any of the layers in use.
+func (b *BloomFilter) test(c *Client, count int64, items []string) ([]bool, error) {
+	last := b.layer(count)
+	var cmds [][]interface{}
+	for _, item := range items {
+		for i := 0; i <= last; i++ {
+			for _, offset := range b.offsets(i, item) {
+				key, bit := b.bit(i, offset)
+				cmds = append(cmds, []interface{}{"getbit", key, bit})
+			}
+		}
+	}
+	resps, err := b.pipeline(c, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]bool, len(items))
+	for j := range items {
+		for i := 0; i <= last; i++ {
+			all := true
+			for _, resp := range resps[:b.layers[i].hashes] {
+				all = all && resp[1] == "1"
+			}
+			found[j] = found[j] || all
+			resps = resps[b.layers[i].hashes:]
+		}
+	}
+	return found, nil
+}
+
+// offsets returns the bit offsets of the item in the layer, by double hashing.
+func (b *BloomFilter) offsets(layer int, item string) []uint64 {
+	h := fnv.New128a()
+	h.Write([]byte(item))
+	sum := h.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	h2 := binary.BigEndian.Uint64(sum[8:]) | 1
+
+	l := b.layers[layer]
+	offsets := make([]uint64, l.hashes)
+	for i := range offsets {
+		offsets[i] = (h1 + uint64(i)*h2) % l.bits
+	}
+	return offsets
+}
+
+func (b *BloomFilter) key(layer int, shard uint64) string {
+	return b.name + ":" + strconv.Itoa(layer) + ":" + strconv.FormatUint(shard, 10)
+}
+
+// bit returns the key and the bit offset in the key of the offset in the layer.
+func (b *BloomFilter) bit(layer int, offset uint64) (string, uint64) {
+	return b.key(layer, offset/bloomShardBits), offset % bloomShardBits
+}
+
+// pipeline runs the commands in batches, and checks their responses.
+func (b *BloomFilter) pipeline(c *Client, cmds [][]interface{}) ([][]string, error) {
+	resps := make([][]string, 0, len(cmds))
+	for len(cmds) > 0 {
+		n := min(len(cmds), bloomBatch)
+		batch, err := c.pipeline(cmds[:n])
+		if err != nil {
+			return nil, err
+		}
+		for i, resp := range batch {
+			switch {
+			case len(resp) == 0:
+				return nil, errors.New("no response received")
+			case resp[0] == "not_found":
+				// the bits of a key not existed are 0.
+				batch[i] = []string{"ok", "0"}
+			case resp[0] != "ok":
+				return nil, errors.New(resp[0])
+			}
+		}
+		resps = append(resps, batch...)
+		cmds = cmds[n:]
+	}
+	return resps, nil
+}
@@ -0,0 +1,115 @@
+package ssdb
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBloomLayers(t *testing.T) {
+	b, err := NewBloomFilter(nil, "bloom", 1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewBloomFilter failed, err:%v\n", err)
+	}
+	// m = -n*ln(p)/ln(2)^2, k = m/n*ln(2), for p = 0.01/2.
+	l := b.layers[0]
+	if l.capacity != 1000 || l.bits != 11028 || l.hashes != 8 {
+		t.Fatalf("NewBloomFilter failed, got:%+v\n", l)
+	}
+	if l := b.layers[1]; l.capacity != 2000 || l.hashes != 9 {
+		t.Fatalf("NewBloomFilter failed, got:%+v\n", l)
+	}
+
+	for _, tt := range []struct {
+		count int64
+		layer int
+	}{{0, 0}, {999, 0}, {1000, 1}, {2999, 1}, {3000, 2}} {
+		if layer := b.layer(tt.count); layer != tt.layer {
+			t.Fatalf("layer(%v) failed, expected:%v, got:%v\n", tt.count, tt.layer, layer)
+		}
+	}
+
+	offsets := b.offsets(0, "item")
+	if len(offsets) != l.hashes {
+		t.Fatalf("offsets failed, got:%v\n", offsets)
+	}
+	for _, offset := range offsets {
+		if offset >= l.bits {
+			t.Fatalf("offsets failed, %v out of range\n", offset)
+		}
+	}
+
+	for _, args := range []struct {
+		capacity int64
+		fpRate   float64
+	}{{0, 0.01}, {100, 0}, {100, 1}} {
+		if _, err := NewBloomFilter(nil, "bloom", args.capacity, args.fpRate); err == nil {
+			t.Fatalf("NewBloomFilter(%v, %v) failed, expected an error\n", args.capacity, args.fpRate)
+		}
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBloomFilter(p, "bloom_test", 100, 0.01)
+	if err != nil {
+		t.Fatalf("NewBloomFilter failed, err:%v\n", err)
+	}
+	defer b.Clear()
+
+	var items []string
+	for i := 0; i < 300; i++ {
+		items = append(items, "item"+strconv.Itoa(i))
+	}
+	added, err := b.AddMulti(items...)
+	if err != nil {
+		t.Fatalf("AddMulti failed, err:%v\n", err)
+	}
+	ok, err := b.Add(items[0])
+	if err != nil || ok {
+		t.Fatalf("Add failed, expected not added, got:%v, err:%v\n", ok, err)
+	}
+	// false positives are not added.
+	var n int64
+	for _, ok := range added {
+		if ok {
+			n++
+		}
+	}
+	count, err := b.Count()
+	if err != nil || count != n {
+		t.Fatalf("Count failed, expected:%v, got:%v, err:%v\n", n, count, err)
+	}
+
+	found, err := b.TestMulti(items...)
+	if err != nil {
+		t.Fatalf("TestMulti failed, err:%v\n", err)
+	}
+	for i, ok := range found {
+		if !ok {
+			t.Fatalf("TestMulti failed, %v not found\n", items[i])
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		ok, err := b.Test("absent" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("Test failed, err:%v\n", err)
+		}
+		if ok {
+			falsePositives++
+		}
+	}
+	if falsePositives > 30 {
+		t.Fatalf("Test failed, %v false positives of 1000\n", falsePositives)
+	}
+
+	ratio, err := b.FillRatio()
+	if err != nil || ratio <= 0 || ratio >= 1 {
+		t.Fatalf("FillRatio failed, got:%v, err:%v\n", ratio, err)
+	}
+}
@@ -0,0 +1,118 @@
+package ssdb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec marshals Go values to the bytes stored in the server, and unmarshals them back.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec encodes values with encoding/json, it's the default codec.
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec encodes values with encoding/gob.
+	GobCodec Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// SetCodec sets the codec used by the *Object methods, nil for JSONCodec.
+func (c *Client) SetCodec(codec Codec) {
+	c.codec = codec
+}
+
+// Codec returns the codec used by the *Object methods.
+func (c *Client) Codec() Codec {
+	if c.codec == nil {
+		return JSONCodec
+	}
+	return c.codec
+}
+
+// SetObject sets the value of the key to v encoded by the codec.
+func (c *Client) SetObject(key string, v interface{}) error {
+	data, err := c.Codec().Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, data)
+}
+
+// GetObject decodes the value of the key into v by the codec.
+func (c *Client) GetObject(key string, v interface{}) error {
+	s, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	return c.Codec().Unmarshal([]byte(s), v)
+}
+
+// HsetObject sets the value of the key of a hashmap to v encoded by the codec.
+// Returns 1 if key is a new key in the hashmap and value is set, else returns 0.
+func (c *Client) HsetObject(name, key string, v interface{}) (int64, error) {
+	data, err := c.Codec().Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return c.Hset(name, key, data)
+}
+
+// HgetObject decodes the value of the key of a hashmap into v by the codec.
+func (c *Client) HgetObject(name, key string, v interface{}) error {
+	s, err := c.Hget(name, key)
+	if err != nil {
+		return err
+	}
+	return c.Codec().Unmarshal([]byte(s), v)
+}
+
+// QpushObject pushes the values encoded by the codec like Qpush.
+// Returns the length of the list after the push operation.
+func (c *Client) QpushObject(name string, values ...interface{}) (int64, error) {
+	items := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		data, err := c.Codec().Marshal(v)
+		if err != nil {
+			return 0, err
+		}
+		items = append(items, data)
+	}
+	return c.Qpush(name, items...)
+}
+
+// QpopObject pops one element like Qpop, and decodes it into v by the codec.
+func (c *Client) QpopObject(name string, v interface{}) error {
+	items, err := c.Qpop(name, 1)
+	if err != nil {
+		return err
+	}
+	return c.Codec().Unmarshal([]byte(items[0]), v)
+}
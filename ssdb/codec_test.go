@@ -0,0 +1,60 @@
+package ssdb
+
+import (
+	"testing"
+)
+
+type codecObject struct {
+	Name  string
+	Tags  []string
+	Count int
+}
+
+func TestCodec(t *testing.T) {
+	v := codecObject{Name: "gossdb", Tags: []string{"a", "b"}, Count: 3}
+	for _, codec := range []Codec{JSONCodec, GobCodec} {
+		data, err := codec.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal failed, err:%v\n", err)
+		}
+
+		var got codecObject
+		err = codec.Unmarshal(data, &got)
+		if err != nil {
+			t.Fatalf("Unmarshal failed, err:%v\n", err)
+		}
+		if got.Name != v.Name || len(got.Tags) != 2 || got.Tags[1] != "b" || got.Count != v.Count {
+			t.Fatalf("Unmarshal failed, expected:%+v, got:%+v\n", v, got)
+		}
+	}
+
+	c := &Client{}
+	if c.Codec() != JSONCodec {
+		t.Fatalf("Codec failed, expected JSONCodec by default\n")
+	}
+	c.SetCodec(GobCodec)
+	if c.Codec() != GobCodec {
+		t.Fatalf("SetCodec failed, expected GobCodec\n")
+	}
+}
+
+func TestCodecPool(t *testing.T) {
+	p, err := NewPool("127.0.0.1", 8888, "", 1)
+	if err != nil {
+		t.Fatalf("NewPool failed, err:%v\n", err)
+	}
+	p.Release(&Client{})
+
+	c := p.Get()
+	c.SetCodec(GobCodec)
+	p.Release(c)
+	if c = p.Get(); c.Codec() != JSONCodec {
+		t.Fatalf("Get failed, expected the codec of the pool\n")
+	}
+	p.Release(c)
+
+	p.SetCodec(GobCodec)
+	if c = p.Get(); c.Codec() != GobCodec {
+		t.Fatalf("Get failed, expected GobCodec\n")
+	}
+}
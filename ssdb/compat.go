@@ -0,0 +1,244 @@
+package ssdb
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned when the server doesn't provide the command,
+// and it can't be emulated on the client side.
+var ErrUnsupported = errors.New("command not supported by the server")
+
+// commandSince records the first server version providing the command.
+// The commands not listed here are treated as supported by all versions.
+var commandSince = map[string]string{
+	"flushdb":     "1.9.2",
+	"zpop_front":  "1.9.0",
+	"zpop_back":   "1.9.0",
+	"qtrim_front": "1.7.0",
+	"qtrim_back":  "1.7.0",
+	"bitcount":    "1.7.0",
+
+	"list_allow_ip": "1.9.0",
+	"add_allow_ip":  "1.9.0",
+	"del_allow_ip":  "1.9.0",
+	"list_deny_ip":  "1.9.0",
+	"add_deny_ip":   "1.9.0",
+	"del_deny_ip":   "1.9.0",
+}
+
+// Capabilities describes what the server connected supports.
+type Capabilities struct {
+	// Version is the server version, like 1.9.4, empty if it can't be detected.
+	Version string
+}
+
+// Supports reports whether the server provides the command natively.
+// If the version is unknown, all commands are treated as supported.
+func (caps *Capabilities) Supports(cmd string) bool {
+	since, ok := commandSince[cmd]
+	if !ok || caps.Version == "" {
+		return true
+	}
+	return compareVersion(caps.Version, since) >= 0
+}
+
+// Capabilities detects the server version once per connection, and returns what the server supports.
+func (c *Client) Capabilities() (*Capabilities, error) {
+	if c.caps != nil {
+		return c.caps, nil
+	}
+
+	version, err := c.Version()
+	if err != nil {
+		// the version command is missing in old servers, fallback to info.
+		if c.err != nil {
+			return nil, err
+		}
+		resp, err := c.doReturnStringSlice("info")
+		if err != nil {
+			return nil, err
+		}
+		info := &ServerInfo{}
+		info.parse(resp)
+		version = info.Version
+	}
+
+	c.caps = &Capabilities{Version: version}
+	return c.caps, nil
+}
+
+// supports reports whether the server provides the command natively.
+func (c *Client) supports(cmd string) (bool, error) {
+	caps, err := c.Capabilities()
+	if err != nil {
+		return false, err
+	}
+	return caps.Supports(cmd), nil
+}
+
+// require returns ErrUnsupported if the server doesn't provide the command.
+func (c *Client) require(cmd string) error {
+	ok, err := c.supports(cmd)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUnsupported
+	}
+	return nil
+}
+
+// compareVersion compares two dotted versions, returns -1 if a < b, 1 if a > b, otherwise 0.
+func compareVersion(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = leadingInt(as[i])
+		}
+		if i < len(bs) {
+			y = leadingInt(bs[i])
+		}
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
+// leadingInt returns the integer at the beginning of s, like 4 for 4-beta.
+func leadingInt(s string) int {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n, _ := strconv.Atoi(s[:i])
+	return n
+}
+
+// doReturnRange works like doReturnStringSlice, but an empty result is not an error.
+func (c *Client) doReturnRange(args ...interface{}) ([]string, error) {
+	resp, err := c.do(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(resp) == 0:
+		return nil, errors.New("no response received")
+	case resp[0] == "ok":
+		return resp[1:], nil
+	default:
+		return nil, errors.New(resp[0])
+	}
+}
+
+// emulateFlushDB deletes data of the specific type by listing and deleting them batch by batch.
+func (c *Client) emulateFlushDB(dataType string) error {
+	const batch = 1000
+
+	if dataType == "" || dataType == "kv" {
+		for {
+			keys, err := c.doReturnRange("keys", "", "", batch)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				break
+			}
+			err = c.doReturn("multi_del", keys)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	clears := []struct{ dataType, list, clear string }{
+		{"hash", "hlist", "hclear"},
+		{"zset", "zlist", "zclear"},
+		{"list", "qlist", "qclear"},
+	}
+	for _, cl := range clears {
+		if dataType != "" && dataType != cl.dataType {
+			continue
+		}
+		for {
+			names, err := c.doReturnRange(cl.list, "", "", batch)
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				break
+			}
+			for _, name := range names {
+				err = c.doReturn(cl.clear, name)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// emulateZpop pops elements from a zset by zrange/zrrange and multi_zdel, it's not atomic.
+func (c *Client) emulateZpop(rangeCmd, name string, limit int) (OrderedMap, error) {
+	resp, err := c.doReturnRange(rangeCmd, name, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, errors.New("no data found")
+	}
+
+	m := newMap(resp)
+	_, err = c.doReturnInt("multi_zdel", name, m.Keys())
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// emulateQtrim trims elements from a queue by popping and dropping them.
+func (c *Client) emulateQtrim(popCmd, name string, size int) (int64, error) {
+	resp, err := c.doReturnRange(popCmd, name, size)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(resp)), nil
+}
+
+// emulateBitcount counts bits by countbit, converting start and end to start and size.
+func (c *Client) emulateBitcount(key string, args []int) (int64, error) {
+	if len(args) < 2 {
+		return c.doReturnInt("countbit", key, args)
+	}
+
+	length, err := c.doReturnInt("strlen", key)
+	if err != nil {
+		return 0, err
+	}
+	start, end := int64(args[0]), int64(args[1])
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end >= length {
+		end = length - 1
+	}
+	if start > end {
+		return 0, nil
+	}
+	return c.doReturnInt("countbit", key, start, end-start+1)
+}
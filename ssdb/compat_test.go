@@ -0,0 +1,42 @@
+package ssdb
+
+import (
+	"testing"
+)
+
+func TestCompareVersion(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.9.2", "1.9.2", 0},
+		{"1.9.4", "1.9.2", 1},
+		{"1.8.0", "1.9.2", -1},
+		{"1.10.0", "1.9.2", 1},
+		{"1.9", "1.9.0", 0},
+		{"1.9.2-beta", "1.9.2", 0},
+	}
+	for _, cs := range cases {
+		if got := compareVersion(cs.a, cs.b); got != cs.expected {
+			t.Fatalf("compareVersion(%v, %v) failed, expected:%v, got:%v\n", cs.a, cs.b, cs.expected, got)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	caps := &Capabilities{Version: "1.8.0"}
+	if caps.Supports("flushdb") {
+		t.Fatalf("Supports failed, flushdb is not supported by %v\n", caps.Version)
+	}
+	if !caps.Supports("qtrim_front") {
+		t.Fatalf("Supports failed, qtrim_front is supported by %v\n", caps.Version)
+	}
+	if !caps.Supports("get") {
+		t.Fatalf("Supports failed, get is supported by %v\n", caps.Version)
+	}
+
+	caps = &Capabilities{}
+	if !caps.Supports("flushdb") {
+		t.Fatalf("Supports failed, unknown version should support all commands\n")
+	}
+}
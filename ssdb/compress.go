@@ -0,0 +1,186 @@
+package ssdb
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// compressMagic is the first byte of the header of compressed values.
+// It's never the first byte of a valid UTF-8 string, so text values can't be mistaken.
+// Values starting with it are always compressed when compression is enabled, to keep them unambiguous.
+const compressMagic byte = 0xfe
+
+// compressVersion follows compressMagic in the header, then the CRC-32 of the uncompressed value,
+// so binary values starting with compressMagic are hardly mistaken for compressed ones.
+const compressVersion byte = 1
+
+// compressHeaderLen is the length of the header of compressed values.
+const compressHeaderLen = 6
+
+// SetCompression enables compressing values not shorter than threshold bytes with flate,
+// for the values written by Set, Setx, Setnx, Getset, MultiSet, Hset, MultiHset, Qpush and Qset,
+// and decompressing values read by Get, MultiGet, Scan, Hget, MultiHget, Hgetall, Hscan, Qpop, Qrange etc.
+// Uncompressed values are read as they are. A threshold not greater than 0 disables compression,
+// and the values are read as they are too, compressed or not.
+// Compressed values start with a 6 bytes header, the byte 0xfe, a version byte and a checksum,
+// the values failing to be decompressed are read as they are, like binary values starting with 0xfe.
+// Caution: bit and substring commands, like Setbit and Substr, work on the compressed bytes.
+func (c *Client) SetCompression(threshold int) {
+	c.compressThreshold = threshold
+}
+
+func (c *Client) compressEnabled() bool {
+	return c.compressThreshold > 0
+}
+
+// packValue compresses the value if it's long enough.
+func (c *Client) packValue(v interface{}) (interface{}, error) {
+	if !c.compressEnabled() {
+		return v, nil
+	}
+
+	data, err := atomBytes(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.compressThreshold && (len(data) == 0 || data[0] != compressMagic) {
+		return v, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{compressMagic, compressVersion})
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(data))
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// packValues compresses the values, slices are flattened like formatData does.
+func (c *Client) packValues(values []interface{}) ([]interface{}, error) {
+	if !c.compressEnabled() {
+		return values, nil
+	}
+	values = flattenArgs(values)
+	for i, v := range values {
+		p, err := c.packValue(v)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = p
+	}
+	return values, nil
+}
+
+// packPairs compresses the values of key-value pairs, slices are flattened like formatData does.
+func (c *Client) packPairs(kvs []interface{}) ([]interface{}, error) {
+	if !c.compressEnabled() {
+		return kvs, nil
+	}
+	kvs = flattenArgs(kvs)
+	for i := 1; i < len(kvs); i += 2 {
+		p, err := c.packValue(kvs[i])
+		if err != nil {
+			return nil, err
+		}
+		kvs[i] = p
+	}
+	return kvs, nil
+}
+
+// flattenArgs expands the slice arguments to a new slice.
+func flattenArgs(args []interface{}) []interface{} {
+	flat := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case []string:
+			for _, s := range arg {
+				flat = append(flat, s)
+			}
+		case []int:
+			for _, d := range arg {
+				flat = append(flat, d)
+			}
+		case []interface{}:
+			flat = append(flat, arg...)
+		default:
+			flat = append(flat, arg)
+		}
+	}
+	return flat
+}
+
+// unpackValue decompresses the value if it has the header of compressed values and
+// compression is enabled, or returns it as it is.
+func (c *Client) unpackValue(s string) string {
+	if !c.compressEnabled() || len(s) < compressHeaderLen || s[0] != compressMagic || s[1] != compressVersion {
+		return s
+	}
+	r := flate.NewReader(strings.NewReader(s[compressHeaderLen:]))
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32([]byte(s[2:compressHeaderLen])) {
+		return s
+	}
+	return string(data)
+}
+
+func (c *Client) unpackString(s string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return c.unpackValue(s), nil
+}
+
+func (c *Client) unpackValues(values []string, err error) ([]string, error) {
+	if err != nil {
+		return values, err
+	}
+	for i := range values {
+		values[i] = c.unpackValue(values[i])
+	}
+	return values, nil
+}
+
+// unpackPairs decompresses the values of key-value pairs in place.
+func (c *Client) unpackPairs(kvs []string, err error) ([]string, error) {
+	if err != nil {
+		return kvs, err
+	}
+	for i := 1; i < len(kvs); i += 2 {
+		kvs[i] = c.unpackValue(kvs[i])
+	}
+	return kvs, nil
+}
+
+// unpackMap decompresses the values of the map, in place for the maps returned by the Client.
+func (c *Client) unpackMap(m OrderedMap, err error) (OrderedMap, error) {
+	if err != nil || !c.compressEnabled() {
+		return m, err
+	}
+	if om, ok := m.(*orderedMap); ok {
+		for i := range om.values {
+			om.values[i] = c.unpackValue(om.values[i])
+		}
+		return om, nil
+	}
+
+	kvs := make([]string, 0, m.Length()*2)
+	for i := 0; i < m.Length(); i++ {
+		k, v := m.Index(i)
+		kvs = append(kvs, k, v)
+	}
+	kvs, _ = c.unpackPairs(kvs, nil)
+	return newMap(kvs), nil
+}
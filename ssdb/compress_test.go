@@ -0,0 +1,103 @@
+package ssdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	c := &Client{}
+	long := strings.Repeat("gossdb ", 100)
+
+	v, err := c.packValue(long)
+	if err != nil {
+		t.Fatalf("packValue failed, err:%v\n", err)
+	}
+	if v != long {
+		t.Fatalf("packValue failed, compressed when disabled\n")
+	}
+
+	c.SetCompression(64)
+	v, err = c.packValue("short")
+	if err != nil {
+		t.Fatalf("packValue failed, err:%v\n", err)
+	}
+	if v != "short" {
+		t.Fatalf("packValue failed, short value compressed\n")
+	}
+
+	v, err = c.packValue(long)
+	if err != nil {
+		t.Fatalf("packValue failed, err:%v\n", err)
+	}
+	packed := string(v.([]byte))
+	if packed[0] != compressMagic || len(packed) >= len(long) {
+		t.Fatalf("packValue failed, value not compressed, len:%v\n", len(packed))
+	}
+	if got := c.unpackValue(packed); got != long {
+		t.Fatalf("unpackValue failed, expected:%v, got:%v\n", long, got)
+	}
+	// compressed values are read as they are with compression disabled.
+	if got := (&Client{}).unpackValue(packed); got != packed {
+		t.Fatalf("unpackValue failed, decompressed when disabled\n")
+	}
+
+	// the short value starting with the magic byte is compressed, to be unambiguous.
+	magic := string([]byte{compressMagic, 'x'})
+	v, err = c.packValue(magic)
+	if err != nil {
+		t.Fatalf("packValue failed, err:%v\n", err)
+	}
+	if got := c.unpackValue(string(v.([]byte))); got != magic {
+		t.Fatalf("unpackValue failed, expected:%q, got:%q\n", magic, got)
+	}
+
+	// legacy values are read as they are.
+	for _, legacy := range []string{"", "legacy"} {
+		if got := c.unpackValue(legacy); got != legacy {
+			t.Fatalf("unpackValue failed, expected:%q, got:%q\n", legacy, got)
+		}
+	}
+	// so are binary values starting with the magic byte, with compression enabled or not.
+	corrupt := []byte(packed)
+	corrupt[len(corrupt)-1] ^= 1
+	for _, raw := range []string{"\xfe", "\xfe\x01\x02binary", string([]byte{compressMagic, compressVersion, 0, 0, 0, 0, 1}), string(corrupt)} {
+		for _, reader := range []*Client{c, {}} {
+			if got := reader.unpackValue(raw); got != raw {
+				t.Fatalf("unpackValue failed, expected:%q, got:%q\n", raw, got)
+			}
+		}
+	}
+
+	kvs, err := c.packPairs([]interface{}{"k1", long, []string{"k2", long}})
+	if err != nil {
+		t.Fatalf("packPairs failed, err:%v\n", err)
+	}
+	if len(kvs) != 4 || kvs[0] != "k1" || kvs[2] != "k2" {
+		t.Fatalf("packPairs failed, got:%v\n", kvs)
+	}
+	var ss []string
+	for _, kv := range kvs {
+		switch kv := kv.(type) {
+		case string:
+			ss = append(ss, kv)
+		case []byte:
+			ss = append(ss, string(kv))
+		}
+	}
+	ss, _ = c.unpackPairs(ss, nil)
+	if ss[1] != long || ss[3] != long {
+		t.Fatalf("unpackPairs failed\n")
+	}
+
+	m, err := c.unpackMap(newMap([]string{"k1", packed, "k2", "v2"}), nil)
+	if err != nil {
+		t.Fatalf("unpackMap failed, err:%v\n", err)
+	}
+	if v, _ := m.Lookup("k1"); v != long {
+		t.Fatalf("unpackMap failed, expected:%v, got:%v\n", long, v)
+	}
+	if v, _ := m.Lookup("k2"); v != "v2" {
+		t.Fatalf("unpackMap failed, expected:%v, got:%v\n", "v2", v)
+	}
+}
@@ -0,0 +1,320 @@
+package ssdb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// encryptMagic is the header byte of encrypted values, followed by
+// the key id length(1 byte), the key id, the nonce and the sealed data.
+// Like compressMagic, it's never the first byte of a valid UTF-8 string.
+const encryptMagic byte = 0xfd
+
+// EncryptOptions configures the encryption of a Client, see Client.SetEncryption.
+type EncryptOptions struct {
+	// Keys maps key ids to AES keys of 16, 24 or 32 bytes.
+	// The key id is embedded in the ciphertext, so old keys kept here still decrypt after rotation.
+	Keys map[string][]byte
+	// CurrentKeyID is the id of the key used to encrypt.
+	CurrentKeyID string
+	// EncryptKeys encrypts the keys of kv and hashmaps too, deterministically so they can still be looked up.
+	// Range scans on encrypted keys are meaningless, and data written with an old key can't be
+	// looked up by key after rotation, so keep it false unless the keys are sensitive too.
+	// Names of hashmaps, zsets and queues, zset keys and scores always stay in plaintext.
+	EncryptKeys bool
+}
+
+// encryptor seals and opens values with AES-GCM.
+type encryptor struct {
+	aeads       map[string]cipher.AEAD
+	nonceKeys   map[string][]byte
+	current     string
+	encryptKeys bool
+}
+
+func newEncryptor(opts *EncryptOptions) (*encryptor, error) {
+	if _, ok := opts.Keys[opts.CurrentKeyID]; !ok {
+		return nil, fmt.Errorf("current key %q not found", opts.CurrentKeyID)
+	}
+
+	e := &encryptor{
+		aeads:       make(map[string]cipher.AEAD),
+		nonceKeys:   make(map[string][]byte),
+		current:     opts.CurrentKeyID,
+		encryptKeys: opts.EncryptKeys,
+	}
+	for id, key := range opts.Keys {
+		if len(id) > 255 {
+			return nil, fmt.Errorf("key id %q too long", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", id, err)
+		}
+		e.aeads[id] = aead
+		// the key deriving nonces for deterministic encryption.
+		h := sha256.Sum256(append([]byte("gossdb nonce key:"), key...))
+		e.nonceKeys[id] = h[:]
+	}
+	return e, nil
+}
+
+// SetEncryption makes the Client encrypt values with AES-GCM before they are sent, and decrypt
+// them after they are read, nil opts disables encryption. It's applied to every command writing
+// or reading values, like Set, Hset, MultiSet, Qpush, Get, Hgetall, Scan, Qrange, and so to
+// the *Object, *Struct and iterator methods built on them. Values are compressed before encrypted
+// if compression is enabled. Values not encrypted are read as they are, so existing data can be
+// migrated gradually.
+// Caution: incr, hincr, bit and substring commands work on the ciphertext.
+func (c *Client) SetEncryption(opts *EncryptOptions) error {
+	if opts == nil {
+		c.encryptor = nil
+		return nil
+	}
+	e, err := newEncryptor(opts)
+	if err != nil {
+		return err
+	}
+	c.encryptor = e
+	return nil
+}
+
+// seal encrypts the data with the current key, the nonce is derived from the data if deterministic.
+func (e *encryptor) seal(data []byte, deterministic bool) ([]byte, error) {
+	aead := e.aeads[e.current]
+	header := append([]byte{encryptMagic, byte(len(e.current))}, e.current...)
+
+	var nonce []byte
+	if deterministic {
+		mac := hmac.New(sha256.New, e.nonceKeys[e.current])
+		mac.Write(data)
+		nonce = mac.Sum(nil)[:aead.NonceSize()]
+	} else {
+		nonce = make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	out := append(header, nonce...)
+	return aead.Seal(out, nonce, data, header), nil
+}
+
+// open decrypts the data sealed, or returns it as it is if not encrypted.
+func (e *encryptor) open(s string) (string, error) {
+	if len(s) < 2 || s[0] != encryptMagic {
+		return s, nil
+	}
+	idLen := int(s[1])
+	if len(s) < 2+idLen {
+		return "", errors.New("bad encrypted value")
+	}
+	id := s[2 : 2+idLen]
+	aead, ok := e.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("key %q not found for decrypting", id)
+	}
+
+	data := []byte(s)
+	header, rest := data[:2+idLen], data[2+idLen:]
+	if len(rest) < aead.NonceSize() {
+		return "", errors.New("bad encrypted value")
+	}
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// How the keys or values in the arguments of a command are encrypted.
+const (
+	encNone = iota
+	// encOne encrypts the argument at the position only.
+	encOne
+	// encAll encrypts all the arguments from the position.
+	encAll
+	// encPairs encrypts every other argument from the position, like the keys or values of pairs.
+	encPairs
+)
+
+// How the response of a command is decrypted.
+const (
+	encRespNone = iota
+	// encRespValue decrypts the single value.
+	encRespValue
+	// encRespValues decrypts all the items as values.
+	encRespValues
+	// encRespKeys decrypts all the items as keys.
+	encRespKeys
+	// encRespPairs decrypts the keys and values of key-value pairs.
+	encRespPairs
+	// encRespKeyPairs decrypts the keys of key-value pairs only, the values are not stored values.
+	encRespKeyPairs
+)
+
+type encRule struct {
+	keys    int
+	keyAt   int
+	values  int
+	valueAt int
+	resp    int
+}
+
+// encRules records the commands taking or returning keys of kv and hashmaps, or values.
+var encRules = map[string]encRule{
+	// kv
+	"get":          {keys: encOne, keyAt: 1, resp: encRespValue},
+	"set":          {keys: encOne, keyAt: 1, values: encOne, valueAt: 2},
+	"setx":         {keys: encOne, keyAt: 1, values: encOne, valueAt: 2},
+	"setnx":        {keys: encOne, keyAt: 1, values: encOne, valueAt: 2},
+	"getset":       {keys: encOne, keyAt: 1, values: encOne, valueAt: 2, resp: encRespValue},
+	"del":          {keys: encOne, keyAt: 1},
+	"exists":       {keys: encOne, keyAt: 1},
+	"expire":       {keys: encOne, keyAt: 1},
+	"ttl":          {keys: encOne, keyAt: 1},
+	"incr":         {keys: encOne, keyAt: 1},
+	"decr":         {keys: encOne, keyAt: 1},
+	"setbit":       {keys: encOne, keyAt: 1},
+	"getbit":       {keys: encOne, keyAt: 1},
+	"countbit":     {keys: encOne, keyAt: 1},
+	"bitcount":     {keys: encOne, keyAt: 1},
+	"substr":       {keys: encOne, keyAt: 1},
+	"strlen":       {keys: encOne, keyAt: 1},
+	"multi_get":    {keys: encAll, keyAt: 1, resp: encRespPairs},
+	"multi_del":    {keys: encAll, keyAt: 1},
+	"multi_exists": {keys: encAll, keyAt: 1, resp: encRespKeyPairs},
+	"multi_set":    {keys: encPairs, keyAt: 1, values: encPairs, valueAt: 2},
+	"keys":         {resp: encRespKeys},
+	"rkeys":        {resp: encRespKeys},
+	"scan":         {resp: encRespPairs},
+	"rscan":        {resp: encRespPairs},
+	// hashmap
+	"hget":          {keys: encOne, keyAt: 2, resp: encRespValue},
+	"hset":          {keys: encOne, keyAt: 2, values: encOne, valueAt: 3},
+	"hdel":          {keys: encOne, keyAt: 2},
+	"hincr":         {keys: encOne, keyAt: 2},
+	"hexists":       {keys: encOne, keyAt: 2},
+	"multi_hget":    {keys: encAll, keyAt: 2, resp: encRespPairs},
+	"multi_hdel":    {keys: encAll, keyAt: 2},
+	"multi_hexists": {keys: encAll, keyAt: 2, resp: encRespKeyPairs},
+	"multi_hset":    {keys: encPairs, keyAt: 2, values: encPairs, valueAt: 3},
+	"hkeys":         {resp: encRespKeys},
+	"hgetall":       {resp: encRespPairs},
+	"hscan":         {resp: encRespPairs},
+	"hrscan":        {resp: encRespPairs},
+	// queue
+	"qpush":       {values: encAll, valueAt: 2},
+	"qpush_front": {values: encAll, valueAt: 2},
+	"qpush_back":  {values: encAll, valueAt: 2},
+	"qset":        {values: encOne, valueAt: 3},
+	"qpop":        {resp: encRespValues},
+	"qpop_front":  {resp: encRespValues},
+	"qpop_back":   {resp: encRespValues},
+	"qfront":      {resp: encRespValue},
+	"qback":       {resp: encRespValue},
+	"qget":        {resp: encRespValue},
+	"qrange":      {resp: encRespValues},
+	"qslice":      {resp: encRespValues},
+}
+
+// encryptArgs returns the arguments with values encrypted, and keys too if keys are encrypted.
+func (c *Client) encryptArgs(args []interface{}) ([]interface{}, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	cmd, _ := args[0].(string)
+	rule, ok := encRules[cmd]
+	if !ok || (rule.values == encNone && (rule.keys == encNone || !c.encryptor.encryptKeys)) {
+		return args, nil
+	}
+
+	args = flattenArgs(args)
+	if c.encryptor.encryptKeys {
+		if err := c.sealArgs(args, rule.keys, rule.keyAt, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.sealArgs(args, rule.values, rule.valueAt, false); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// sealArgs encrypts the arguments in place, keys are encrypted deterministically.
+func (c *Client) sealArgs(args []interface{}, how, at int, deterministic bool) error {
+	step := 1
+	switch how {
+	case encNone:
+		return nil
+	case encOne:
+		if at < len(args) {
+			return c.sealArg(args, at, deterministic)
+		}
+		return nil
+	case encPairs:
+		step = 2
+	}
+	for i := at; i < len(args); i += step {
+		if err := c.sealArg(args, i, deterministic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) sealArg(args []interface{}, i int, deterministic bool) error {
+	data, err := atomBytes(args[i])
+	if err != nil {
+		return err
+	}
+	sealed, err := c.encryptor.seal(data, deterministic)
+	if err != nil {
+		return err
+	}
+	args[i] = sealed
+	return nil
+}
+
+// decryptResp decrypts the values in the response, and the keys too if keys are encrypted.
+func (c *Client) decryptResp(cmd interface{}, resp []string) ([]string, error) {
+	name, _ := cmd.(string)
+	rule, ok := encRules[name]
+	if !ok || rule.resp == encRespNone || len(resp) < 2 || resp[0] != "ok" {
+		return resp, nil
+	}
+
+	keys := c.encryptor.encryptKeys
+	var err error
+	for i := 1; i < len(resp); i++ {
+		var open bool
+		switch rule.resp {
+		case encRespValue:
+			open = i == 1
+		case encRespValues:
+			open = true
+		case encRespKeys:
+			open = keys
+		case encRespPairs:
+			open = i%2 == 0 || keys
+		case encRespKeyPairs:
+			open = i%2 == 1 && keys
+		}
+		if !open {
+			continue
+		}
+		if resp[i], err = c.encryptor.open(resp[i]); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,200 @@
+package ssdb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryption(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 16)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	old := &Client{}
+	err := old.SetEncryption(&EncryptOptions{
+		Keys:         map[string][]byte{"k1": oldKey},
+		CurrentKeyID: "k1",
+	})
+	if err != nil {
+		t.Fatalf("SetEncryption failed, err:%v\n", err)
+	}
+	c := &Client{}
+	err = c.SetEncryption(&EncryptOptions{
+		Keys:         map[string][]byte{"k1": oldKey, "k2": newKey},
+		CurrentKeyID: "k2",
+		EncryptKeys:  true,
+	})
+	if err != nil {
+		t.Fatalf("SetEncryption failed, err:%v\n", err)
+	}
+
+	value := "pii value"
+	args, err := old.encryptArgs([]interface{}{"set", "user:1", value})
+	if err != nil {
+		t.Fatalf("encryptArgs failed, err:%v\n", err)
+	}
+	if args[1] != "user:1" {
+		t.Fatalf("encryptArgs failed, keys encrypted when disabled\n")
+	}
+	ciphertext := string(args[2].([]byte))
+	if ciphertext[0] != encryptMagic || strings.Contains(ciphertext, value) {
+		t.Fatalf("encryptArgs failed, value not encrypted\n")
+	}
+
+	// the value sealed by the old key is still readable after rotation.
+	resp, err := c.decryptResp("get", []string{"ok", ciphertext})
+	if err != nil {
+		t.Fatalf("decryptResp failed, err:%v\n", err)
+	}
+	if resp[1] != value {
+		t.Fatalf("decryptResp failed, expected:%v, got:%v\n", value, resp[1])
+	}
+
+	// plaintext values are read as they are.
+	resp, err = c.decryptResp("qrange", []string{"ok", "legacy"})
+	if err != nil || resp[1] != "legacy" {
+		t.Fatalf("decryptResp failed, expected:%v, got:%v %v\n", "legacy", resp, err)
+	}
+
+	tampered := []byte(ciphertext)
+	tampered[len(tampered)-1] ^= 1
+	if _, err = c.decryptResp("get", []string{"ok", string(tampered)}); err == nil {
+		t.Fatalf("decryptResp failed, expected error for tampered value\n")
+	}
+
+	// keys are encrypted deterministically.
+	a1, err := c.encryptArgs([]interface{}{"get", "user:1"})
+	if err != nil {
+		t.Fatalf("encryptArgs failed, err:%v\n", err)
+	}
+	a2, _ := c.encryptArgs([]interface{}{"hget", "h", "user:1"})
+	k := a1[1].([]byte)
+	if !bytes.Equal(k, a2[2].([]byte)) {
+		t.Fatalf("encryptArgs failed, expected deterministic keys\n")
+	}
+
+	resp, err = c.decryptResp("hgetall", []string{"ok", string(k), ciphertext})
+	if err != nil {
+		t.Fatalf("decryptResp failed, err:%v\n", err)
+	}
+	if resp[1] != "user:1" || resp[2] != value {
+		t.Fatalf("decryptResp failed, got:%v\n", resp)
+	}
+	// the values of multi_exists are not stored values.
+	resp, err = c.decryptResp("multi_exists", []string{"ok", string(k), "1"})
+	if err != nil || resp[1] != "user:1" || resp[2] != "1" {
+		t.Fatalf("decryptResp failed, got:%v, err:%v\n", resp, err)
+	}
+
+	// compression is applied before encryption.
+	c.SetCompression(16)
+	long := string(bytes.Repeat([]byte("a"), 1024))
+	packed, err := c.packValue(long)
+	if err != nil {
+		t.Fatalf("packValue failed, err:%v\n", err)
+	}
+	args, err = c.encryptArgs([]interface{}{"qpush", "q", packed})
+	if err != nil {
+		t.Fatalf("encryptArgs failed, err:%v\n", err)
+	}
+	sealed := string(args[2].([]byte))
+	if len(sealed) >= len(long) {
+		t.Fatalf("encryptArgs failed, value not compressed\n")
+	}
+	got, err := c.unpackValues(c.decryptResp("qpop", []string{"ok", sealed}))
+	if err != nil || got[1] != long {
+		t.Fatalf("decryptResp failed, err:%v\n", err)
+	}
+
+	if err = c.SetEncryption(&EncryptOptions{CurrentKeyID: "none"}); err == nil {
+		t.Fatalf("SetEncryption failed, expected error for absent current key\n")
+	}
+}
+
+func TestEncryptionWriters(t *testing.T) {
+	s, c := newRecordingServer(t)
+	defer s.ln.Close()
+	defer c.Close()
+	err := c.SetEncryption(&EncryptOptions{
+		Keys:         map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)},
+		CurrentKeyID: "k1",
+		EncryptKeys:  true,
+	})
+	if err != nil {
+		t.Fatalf("SetEncryption failed, err:%v\n", err)
+	}
+
+	const secret = "pii-secret"
+	type record struct {
+		Email string `ssdb:"email"`
+	}
+	writers := map[string]func() error{
+		"Set":        func() error { return c.Set(secret, secret) },
+		"Setx":       func() error { return c.Setx(secret, secret, 10) },
+		"Setnx":      func() error { _, err := c.Setnx(secret, secret); return err },
+		"Getset":     func() error { _, err := c.Getset(secret, secret); return err },
+		"MultiSet":   func() error { _, err := c.MultiSet(secret, secret); return err },
+		"Hset":       func() error { _, err := c.Hset("h", secret, secret); return err },
+		"MultiHset":  func() error { _, err := c.MultiHset("h", secret, secret); return err },
+		"Qpush":      func() error { _, err := c.Qpush("q", secret); return err },
+		"QpushFront": func() error { _, err := c.QpushFront("q", secret); return err },
+		"QpushBack":  func() error { _, err := c.QpushBack("q", secret); return err },
+		"Qset":       func() error { return c.Qset("q", 0, secret) },
+		"SetObject":  func() error { return c.SetObject(secret, record{secret}) },
+		"HsetObject": func() error { _, err := c.HsetObject("h", secret, record{secret}); return err },
+		"QpushObject": func() error {
+			_, err := c.QpushObject("q", record{secret})
+			return err
+		},
+		"HsetStruct": func() error { _, err := c.HsetStruct("h", record{secret}); return err },
+		"TimeSeries": func() error {
+			return NewTimeSeries(c, "ts:", nil).Add("s", time.UnixMilli(1), 1)
+		},
+	}
+	for name, write := range writers {
+		if err := write(); err != nil {
+			t.Fatalf("%s failed, err:%v\n", name, err)
+		}
+		reqs := s.requests()
+		if len(reqs) == 0 {
+			t.Fatalf("%s failed, no request sent\n", name)
+		}
+		for _, req := range reqs {
+			var sealed bool
+			for _, arg := range req[1:] {
+				if strings.Contains(arg, secret) {
+					t.Fatalf("%s failed, plaintext sent:%q\n", name, req)
+				}
+				sealed = sealed || (arg != "" && arg[0] == encryptMagic)
+			}
+			if !sealed {
+				t.Fatalf("%s failed, no value encrypted:%q\n", name, req)
+			}
+		}
+	}
+}
+
+func TestEncryptionPipelineError(t *testing.T) {
+	s, c := newRecordingServer(t)
+	defer s.ln.Close()
+	defer c.Close()
+	err := c.SetEncryption(&EncryptOptions{
+		Keys:         map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)},
+		CurrentKeyID: "k1",
+	})
+	if err != nil {
+		t.Fatalf("SetEncryption failed, err:%v\n", err)
+	}
+
+	s.reply("get", "ok", string([]byte{encryptMagic, 2, 'k', '1'}))
+	s.reply("strlen", "ok", "7")
+	if _, err = c.pipeline([][]interface{}{{"get", "a"}, {"set", "b", "v"}}); err == nil {
+		t.Fatalf("pipeline failed, expected error for bad encrypted value\n")
+	}
+	// the responses after the bad one are read off too.
+	resp, err := c.do("strlen", "b")
+	if err != nil || len(resp) != 2 || resp[1] != "7" {
+		t.Fatalf("do failed, expected:%v, got:%v, err:%v\n", 7, resp, err)
+	}
+}
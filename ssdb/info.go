@@ -0,0 +1,248 @@
+package ssdb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ServerInfo is the structured form of the info command output.
+type ServerInfo struct {
+	Version    string
+	Links      int64
+	TotalCalls int64
+	DBSize     int64
+	Binlog     BinlogInfo
+	// Slaves are the clients syncing binlogs from this server.
+	Slaves []ReplicationInfo
+	// Peers are the masters this server is a slave of.
+	Peers []ReplicationInfo
+	// Commands holds the call stats, indexed by command name, like get.
+	Commands map[string]CommandStats
+	// LevelDB holds the rows of the leveldb compaction stats table.
+	LevelDB []LevelDBStats
+	// Extra holds the items not parsed into the fields above, like serv_key_range.
+	Extra map[string]string
+}
+
+// BinlogInfo describes the binlog queue of the server.
+type BinlogInfo struct {
+	Capacity int64
+	MinSeq   int64
+	MaxSeq   int64
+}
+
+// ReplicationInfo describes one replication link.
+type ReplicationInfo struct {
+	// Addr is the ip:port of the remote side.
+	Addr string
+	// ID is the slave id, only reported for peers.
+	ID     string
+	Type   string
+	Status string
+	// LastSeq is the last binlog sequence copied through the link.
+	LastSeq   int64
+	CopyCount int64
+	SyncCount int64
+	// Lag is the number of binlogs the slave is behind this server, -1 if unknown.
+	Lag int64
+}
+
+// CommandStats holds the call stats of one command.
+type CommandStats struct {
+	Calls int64
+	// TimeWait and TimeProc are the total time in milliseconds
+	// the command spent waiting and processing.
+	TimeWait float64
+	TimeProc float64
+}
+
+// LevelDBStats is one row of the leveldb compaction stats table.
+type LevelDBStats struct {
+	Level   int
+	Files   int64
+	SizeMB  float64
+	TimeSec float64
+	ReadMB  float64
+	WriteMB float64
+}
+
+// ServerInfo returns the parsed output of info cmd and info leveldb.
+func (c *Client) ServerInfo() (*ServerInfo, error) {
+	info := &ServerInfo{
+		Commands: make(map[string]CommandStats),
+		Extra:    make(map[string]string),
+	}
+	for _, dataType := range []string{"cmd", "leveldb"} {
+		resp, err := c.doReturnStringSlice("info", dataType)
+		if err != nil {
+			return nil, err
+		}
+		info.parse(resp)
+	}
+	info.computeLag()
+	return info, nil
+}
+
+// parse parses the lines of info output, without the leading status code.
+func (info *ServerInfo) parse(lines []string) {
+	if len(lines) > 0 && lines[0] == "ssdb-server" {
+		lines = lines[1:]
+	}
+	if info.Commands == nil {
+		info.Commands = make(map[string]CommandStats)
+	}
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+
+	// Every info output reports all the replication links, keep the last ones.
+	info.Slaves = info.Slaves[:0]
+	info.Peers = info.Peers[:0]
+
+	for i := 0; i+1 < len(lines); i += 2 {
+		key, value := lines[i], lines[i+1]
+		switch {
+		case key == "version":
+			info.Version = value
+		case key == "links":
+			info.Links = parseInt(value)
+		case key == "total_calls":
+			info.TotalCalls = parseInt(value)
+		case key == "dbsize":
+			info.DBSize = parseInt(value)
+		case key == "binlogs":
+			fields := parseFields(value)
+			info.Binlog.Capacity = parseInt(fields["capacity"])
+			info.Binlog.MinSeq = parseInt(fields["min_seq"])
+			info.Binlog.MaxSeq = parseInt(fields["max_seq"])
+		case key == "replication":
+			info.parseReplication(value)
+		case key == "leveldb.stats":
+			info.LevelDB = parseLevelDBStats(value)
+		case strings.HasPrefix(key, "cmd."):
+			info.Commands[key[len("cmd."):]] = parseCommandStats(value)
+		default:
+			info.Extra[key] = value
+		}
+	}
+}
+
+// parseReplication parses a replication item, which is like:
+//
+//	client 127.0.0.1:55242
+//	    type     : sync
+//	    status   : SYNC
+//	    last_seq : 0
+func (info *ServerInfo) parseReplication(value string) {
+	var head string
+	if idx := strings.IndexByte(value, '\n'); idx >= 0 {
+		head = value[:idx]
+	} else {
+		head = value
+	}
+	role, addr, _ := strings.Cut(strings.TrimSpace(head), " ")
+	fields := parseFields(value)
+	r := ReplicationInfo{
+		Addr:      strings.TrimSpace(addr),
+		ID:        fields["id"],
+		Type:      fields["type"],
+		Status:    fields["status"],
+		LastSeq:   parseInt(fields["last_seq"]),
+		CopyCount: parseInt(fields["copy_count"]),
+		SyncCount: parseInt(fields["sync_count"]),
+		Lag:       -1,
+	}
+
+	switch role {
+	case "client":
+		info.Slaves = append(info.Slaves, r)
+	case "slaveof":
+		info.Peers = append(info.Peers, r)
+	}
+}
+
+// computeLag computes the lag of the slaves against the binlog of this server.
+// The lag of the peers can't be known without asking the masters.
+func (info *ServerInfo) computeLag() {
+	for i := range info.Slaves {
+		s := &info.Slaves[i]
+		if s.LastSeq <= info.Binlog.MaxSeq {
+			s.Lag = info.Binlog.MaxSeq - s.LastSeq
+		} else {
+			s.Lag = 0
+		}
+	}
+}
+
+// parseFields parses the "name : value" lines in a multi-line item.
+func parseFields(value string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(value, "\n") {
+		name, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields[strings.TrimSpace(name)] = strings.TrimSpace(v)
+	}
+	return fields
+}
+
+// parseCommandStats parses a command item, which is like:
+//
+//	calls: 1	time_wait: 0	time_proc: 0
+func parseCommandStats(value string) CommandStats {
+	var cs CommandStats
+	fields := strings.Fields(value)
+	for i := 0; i+1 < len(fields); i += 2 {
+		v := fields[i+1]
+		switch strings.TrimSuffix(fields[i], ":") {
+		case "calls":
+			cs.Calls = parseInt(v)
+		case "time_wait":
+			cs.TimeWait = parseFloat(v)
+		case "time_proc":
+			cs.TimeProc = parseFloat(v)
+		}
+	}
+	return cs
+}
+
+// parseLevelDBStats parses the leveldb stats table, which is like:
+//
+//	                               Compactions
+//	Level  Files Size(MB) Time(sec) Read(MB) Write(MB)
+//	--------------------------------------------------
+//	  0        1        0         0        0         0
+func parseLevelDBStats(value string) []LevelDBStats {
+	var stats []LevelDBStats
+	for _, line := range strings.Split(value, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 6 {
+			continue
+		}
+		level, err := strconv.Atoi(fields[0])
+		if err != nil {
+			// the header line
+			continue
+		}
+		stats = append(stats, LevelDBStats{
+			Level:   level,
+			Files:   parseInt(fields[1]),
+			SizeMB:  parseFloat(fields[2]),
+			TimeSec: parseFloat(fields[3]),
+			ReadMB:  parseFloat(fields[4]),
+			WriteMB: parseFloat(fields[5]),
+		})
+	}
+	return stats
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
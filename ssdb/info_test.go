@@ -0,0 +1,71 @@
+package ssdb
+
+import (
+	"testing"
+)
+
+func TestParseServerInfo(t *testing.T) {
+	lines := []string{
+		"ssdb-server",
+		"version", "1.9.4",
+		"links", "2",
+		"total_calls", "15",
+		"dbsize", "1024",
+		"binlogs", "    capacity : 20000000\n    min_seq  : 1\n    max_seq  : 100",
+		"replication", "client 127.0.0.1:55242\n    type     : sync\n    status   : SYNC\n    last_seq : 90",
+		"replication", "slaveof 127.0.0.1:8888\n    id         : svc_2\n    type       : mirror\n    status     : COPY\n    last_seq   : 7\n    copy_count : 3\n    sync_count : 4",
+		"serv_key_range", "    kv  : \"\" - \"\"",
+		"leveldb.stats", "                               Compactions\nLevel  Files Size(MB) Time(sec) Read(MB) Write(MB)\n--------------------------------------------------\n  0        1        0         0        0         0\n  1        5        2         1        3         4\n",
+		"cmd.get", "calls: 10\ttime_wait: 1.5\ttime_proc: 2",
+	}
+
+	info := &ServerInfo{}
+	info.parse(lines)
+	info.computeLag()
+
+	if info.Version != "1.9.4" {
+		t.Fatalf("Version failed, expected:%v, got:%v\n", "1.9.4", info.Version)
+	}
+	if info.Links != 2 || info.TotalCalls != 15 || info.DBSize != 1024 {
+		t.Fatalf("parse failed, got:%+v\n", info)
+	}
+	if info.Binlog != (BinlogInfo{Capacity: 20000000, MinSeq: 1, MaxSeq: 100}) {
+		t.Fatalf("Binlog failed, got:%+v\n", info.Binlog)
+	}
+
+	if len(info.Slaves) != 1 {
+		t.Fatalf("Slaves failed, expected:%v, got:%v\n", 1, len(info.Slaves))
+	}
+	s := info.Slaves[0]
+	if s.Addr != "127.0.0.1:55242" || s.Type != "sync" || s.Status != "SYNC" || s.LastSeq != 90 || s.Lag != 10 {
+		t.Fatalf("Slaves failed, got:%+v\n", s)
+	}
+
+	if len(info.Peers) != 1 {
+		t.Fatalf("Peers failed, expected:%v, got:%v\n", 1, len(info.Peers))
+	}
+	peer := info.Peers[0]
+	if peer.Addr != "127.0.0.1:8888" || peer.ID != "svc_2" || peer.Status != "COPY" ||
+		peer.CopyCount != 3 || peer.SyncCount != 4 || peer.Lag != -1 {
+		t.Fatalf("Peers failed, got:%+v\n", peer)
+	}
+
+	cs, ok := info.Commands["get"]
+	if !ok {
+		t.Fatalf("Commands failed, get not found in %v\n", info.Commands)
+	}
+	if cs.Calls != 10 || cs.TimeWait != 1.5 || cs.TimeProc != 2 {
+		t.Fatalf("Commands failed, got:%+v\n", cs)
+	}
+
+	if len(info.LevelDB) != 2 {
+		t.Fatalf("LevelDB failed, expected:%v, got:%v\n", 2, len(info.LevelDB))
+	}
+	if info.LevelDB[1] != (LevelDBStats{Level: 1, Files: 5, SizeMB: 2, TimeSec: 1, ReadMB: 3, WriteMB: 4}) {
+		t.Fatalf("LevelDB failed, got:%+v\n", info.LevelDB[1])
+	}
+
+	if _, ok := info.Extra["serv_key_range"]; !ok {
+		t.Fatalf("Extra failed, serv_key_range not found in %v\n", info.Extra)
+	}
+}
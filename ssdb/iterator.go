@@ -0,0 +1,241 @@
+package ssdb
+
+import (
+	"iter"
+)
+
+// defaultBatch is the page size used when the batch passed to iterators is not positive.
+const defaultBatch = 100
+
+// Iterator walks all the items of a scan-style command page by page,
+// advancing the cursor of the command internally.
+//
+//	it := c.ScanIter("", "", 100)
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Iterator is not goroutine-safe, just like the Client.
+type Iterator struct {
+	// fetch returns the next page, first reports whether it's the first page,
+	// key and value are the last item returned.
+	fetch func(first bool, key, value string) ([]string, error)
+	// The page is key-value pairs or keys only.
+	pairs bool
+	batch int
+
+	page    []string
+	fetched bool
+	end     bool
+	key     string
+	value   string
+	err     error
+}
+
+func newIterator(batch int, pairs bool) *Iterator {
+	if batch <= 0 {
+		batch = defaultBatch
+	}
+	return &Iterator{pairs: pairs, batch: batch}
+}
+
+// Next advances the iterator to the next item, it returns false when there are no more items or error occurs.
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.end {
+			return false
+		}
+		page, err := it.fetch(!it.fetched, it.key, it.value)
+		it.fetched = true
+		if err != nil {
+			it.err = err
+			return false
+		}
+		size := it.batch
+		if it.pairs {
+			size *= 2
+		}
+		if len(page) < size {
+			it.end = true
+		}
+		if it.pairs && len(page)%2 != 0 {
+			page = page[:len(page)-1]
+		}
+		if len(page) == 0 {
+			it.end = true
+			return false
+		}
+		it.page = page
+	}
+
+	it.key = it.page[0]
+	if it.pairs {
+		it.value = it.page[1]
+		it.page = it.page[2:]
+	} else {
+		it.page = it.page[1:]
+	}
+	return true
+}
+
+// Key returns the key of the current item, or the name for the list commands, like Hlist.
+func (it *Iterator) Key() string {
+	return it.key
+}
+
+// Value returns the value of the current item, or the score for zsets.
+// It's empty for the commands returning keys only.
+func (it *Iterator) Value() string {
+	return it.value
+}
+
+// Err returns the error occurred during iteration.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining items for range-over-func, check Err after the loop.
+//
+//	for k, v := range c.ScanIter("", "", 100).All() {
+//		...
+//	}
+func (it *Iterator) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for it.Next() {
+			if !yield(it.key, it.value) {
+				return
+			}
+		}
+	}
+}
+
+// keyIter creates an iterator for the commands taking (start, end, limit) and
+// returning keys or names, such as keys, rkeys, hlist and zlist.
+func (c *Client) keyIter(cmd, start, end string, batch int, pairs bool) *Iterator {
+	it := newIterator(batch, pairs)
+	it.fetch = func(first bool, key, value string) ([]string, error) {
+		if !first {
+			start = key
+		}
+		if pairs {
+			return c.unpackPairs(c.doReturnRange(cmd, start, end, it.batch))
+		}
+		return c.doReturnRange(cmd, start, end, it.batch)
+	}
+	return it
+}
+
+// hashIter creates an iterator for the hash commands taking (name, start, end, limit).
+func (c *Client) hashIter(cmd, name, start, end string, batch int, pairs bool) *Iterator {
+	it := newIterator(batch, pairs)
+	it.fetch = func(first bool, key, value string) ([]string, error) {
+		if !first {
+			start = key
+		}
+		if pairs {
+			return c.unpackPairs(c.doReturnRange(cmd, name, start, end, it.batch))
+		}
+		return c.doReturnRange(cmd, name, start, end, it.batch)
+	}
+	return it
+}
+
+// KeysIter iterates all the keys in range (keyStart, keyEnd], see Keys.
+func (c *Client) KeysIter(keyStart, keyEnd string, batch int) *Iterator {
+	return c.keyIter("keys", keyStart, keyEnd, batch, false)
+}
+
+// RkeysIter works likely KeysIter, but in reverse order.
+func (c *Client) RkeysIter(keyStart, keyEnd string, batch int) *Iterator {
+	return c.keyIter("rkeys", keyStart, keyEnd, batch, false)
+}
+
+// ScanIter iterates all the key-value pairs with keys in range (keyStart, keyEnd], see Scan.
+func (c *Client) ScanIter(keyStart, keyEnd string, batch int) *Iterator {
+	return c.keyIter("scan", keyStart, keyEnd, batch, true)
+}
+
+// RscanIter works likely ScanIter, but in reverse order.
+func (c *Client) RscanIter(keyStart, keyEnd string, batch int) *Iterator {
+	return c.keyIter("rscan", keyStart, keyEnd, batch, true)
+}
+
+// HlistIter iterates all the hashmap names in range (nameStart, nameEnd].
+func (c *Client) HlistIter(nameStart, nameEnd string, batch int) *Iterator {
+	return c.keyIter("hlist", nameStart, nameEnd, batch, false)
+}
+
+// HkeysIter iterates all the keys of a hashmap in range (keyStart, keyEnd].
+func (c *Client) HkeysIter(name, keyStart, keyEnd string, batch int) *Iterator {
+	return c.hashIter("hkeys", name, keyStart, keyEnd, batch, false)
+}
+
+// HscanIter iterates all the key-value pairs of a hashmap with keys in range (keyStart, keyEnd].
+func (c *Client) HscanIter(name, keyStart, keyEnd string, batch int) *Iterator {
+	return c.hashIter("hscan", name, keyStart, keyEnd, batch, true)
+}
+
+// ZlistIter iterates all the zset names in range (nameStart, nameEnd].
+func (c *Client) ZlistIter(nameStart, nameEnd string, batch int) *Iterator {
+	return c.keyIter("zlist", nameStart, nameEnd, batch, false)
+}
+
+// zscanIter creates an iterator over zscan, advancing with the key and score cursor.
+// scoreStart and scoreEnd are int64 or empty string for no limit.
+func (c *Client) zscanIter(name, keyStart string, scoreStart, scoreEnd interface{}, batch int) *Iterator {
+	it := newIterator(batch, true)
+	it.fetch = func(first bool, key, value string) ([]string, error) {
+		if !first {
+			keyStart, scoreStart = key, value
+		}
+		return c.doReturnRange("zscan", name, keyStart, scoreStart, scoreEnd, it.batch)
+	}
+	return it
+}
+
+// ZscanIter iterates all the key-score pairs of a zset, see Zscan for the range.
+func (c *Client) ZscanIter(name, keyStart string, scoreStart, scoreEnd int64, batch int) *Iterator {
+	return c.zscanIter(name, keyStart, scoreStart, scoreEnd, batch)
+}
+
+// ZkeysIter iterates all the keys of a zset, see Zkeys for the range.
+// It's driven by zscan, so Value returns the score of the key.
+func (c *Client) ZkeysIter(name, keyStart string, scoreStart, scoreEnd int64, batch int) *Iterator {
+	return c.zscanIter(name, keyStart, scoreStart, scoreEnd, batch)
+}
+
+// ZrangeIter iterates the key-score pairs of a zset starting from offset.
+// Only the first page is fetched by zrange, the rest are fetched by zscan,
+// so it stays fast after the first page.
+func (c *Client) ZrangeIter(name string, offset, batch int) *Iterator {
+	it := newIterator(batch, true)
+	it.fetch = func(first bool, key, value string) ([]string, error) {
+		if first {
+			return c.doReturnRange("zrange", name, offset, it.batch)
+		}
+		return c.doReturnRange("zscan", name, key, value, "", it.batch)
+	}
+	return it
+}
+
+// QrangeIter iterates the elements of a queue starting from offset, negative offset counts from the end.
+func (c *Client) QrangeIter(name string, offset, batch int) *Iterator {
+	fromEnd := offset < 0
+	it := newIterator(batch, false)
+	it.fetch = func(first bool, key, value string) ([]string, error) {
+		if fromEnd && offset >= 0 {
+			// reached the end of queue.
+			return nil, nil
+		}
+		page, err := c.unpackValues(c.doReturnRange("qrange", name, offset, it.batch))
+		offset += len(page)
+		return page, err
+	}
+	return it
+}
@@ -0,0 +1,298 @@
+package ssdb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LeaderboardPeriod is how often a Leaderboard rolls over to a new board.
+type LeaderboardPeriod int
+
+const (
+	// AllTime never rolls over.
+	AllTime LeaderboardPeriod = iota
+	// Daily rolls over at midnight.
+	Daily
+	// Weekly rolls over at midnight of Monday, weeks are ISO 8601 weeks.
+	Weekly
+)
+
+// LeaderboardOptions configures a Leaderboard.
+type LeaderboardOptions struct {
+	// Period is how often the board rolls over, AllTime by default.
+	Period LeaderboardPeriod
+	// Location is the time zone the periods start in, UTC by default.
+	Location *time.Location
+	// Keep is the number of past boards kept besides the current one, those older are cleared
+	// on rolling over. 0 keeps all of them.
+	Keep int
+}
+
+// Rank is a member ranked in a Leaderboard.
+type Rank struct {
+	Member string
+	Score  int64
+	// Rank starts at 1 for the highest score.
+	Rank int64
+}
+
+// Leaderboard ranks members by scores, highest first, in a zset.
+// The scores are stored negated, so the board is ranked by zrange and zrank, and the members
+// with the same score are ranked by their names in ascending order.
+// For periodic boards, the zset of every period is named by name+":"+period,
+// like "name:20240131" for Daily and "name:2024w05" for Weekly.
+type Leaderboard struct {
+	r    Runner
+	name string
+	opts LeaderboardOptions
+	// at pins the board of a period, zero for the current period.
+	at time.Time
+
+	mu        sync.Mutex
+	lastBoard string
+}
+
+// NewLeaderboard returns a Leaderboard on a Client or a Pool, opts may be nil for the defaults.
+func NewLeaderboard(r Runner, name string, opts *LeaderboardOptions) *Leaderboard {
+	lb := &Leaderboard{r: r, name: name}
+	if opts != nil {
+		lb.opts = *opts
+	}
+	if lb.opts.Location == nil {
+		lb.opts.Location = time.UTC
+	}
+	return lb
+}
+
+// At returns the Leaderboard of the period including t, for reading past boards.
+func (lb *Leaderboard) At(t time.Time) *Leaderboard {
+	return &Leaderboard{r: lb.r, name: lb.name, opts: lb.opts, at: t}
+}
+
+// Board returns the name of the zset of the period including t.
+func (lb *Leaderboard) Board(t time.Time) string {
+	t = t.In(lb.opts.Location)
+	switch lb.opts.Period {
+	case Daily:
+		return lb.name + ":" + t.Format("20060102")
+	case Weekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%s:%dw%02d", lb.name, year, week)
+	}
+	return lb.name
+}
+
+// board returns the name of the zset in use, clearing the expired boards on rolling over.
+func (lb *Leaderboard) board(c *Client) (string, error) {
+	if !lb.at.IsZero() {
+		return lb.Board(lb.at), nil
+	}
+	now := time.Now()
+	board := lb.Board(now)
+	if lb.opts.Period == AllTime || lb.opts.Keep <= 0 {
+		return board, nil
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if board == lb.lastBoard {
+		return board, nil
+	}
+
+	// the newest expired board is Keep+1 periods ago.
+	days := lb.opts.Keep + 1
+	if lb.opts.Period == Weekly {
+		days *= 7
+	}
+	expired := lb.Board(now.AddDate(0, 0, -days))
+	start := lb.name + ":"
+	for {
+		// the names of boards are ordered by their periods.
+		names, err := c.doReturnRange("zlist", start, expired, 100)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range names {
+			if !lb.isBoard(name) {
+				continue
+			}
+			if _, err := c.Zclear(name); err != nil {
+				return "", err
+			}
+		}
+		if len(names) < 100 {
+			break
+		}
+		start = names[len(names)-1]
+	}
+	lb.lastBoard = board
+	return board, nil
+}
+
+// isBoard reports whether the zset name is a board of the period, not another zset sharing the prefix.
+func (lb *Leaderboard) isBoard(name string) bool {
+	suffix, ok := strings.CutPrefix(name, lb.name+":")
+	if !ok {
+		return false
+	}
+	switch lb.opts.Period {
+	case Daily:
+		_, err := time.Parse("20060102", suffix)
+		return len(suffix) == 8 && err == nil
+	case Weekly:
+		var year, week int
+		n, err := fmt.Sscanf(suffix, "%4dw%2d", &year, &week)
+		return len(suffix) == 7 && n == 2 && err == nil && week >= 1 && week <= 53
+	}
+	return false
+}
+
+func (lb *Leaderboard) with(f func(c *Client, board string) error) error {
+	return lb.r.Run(func(c *Client) error {
+		board, err := lb.board(c)
+		if err != nil {
+			return err
+		}
+		return f(c, board)
+	})
+}
+
+// Submit records score for member if it's higher than the best score of member,
+// and returns the best score. Concurrent submits for the same member may lose the best score.
+func (lb *Leaderboard) Submit(member string, score int64) (int64, error) {
+	best := score
+	err := lb.with(func(c *Client, board string) error {
+		old, err := c.Zget(board, member)
+		if err == nil && -old >= score {
+			best = -old
+			return nil
+		}
+		if err != nil && err.Error() != "not_found" {
+			return err
+		}
+		_, err = c.Zset(board, member, -score)
+		return err
+	})
+	return best, err
+}
+
+// Incr adds delta to the score of member, and returns the new score.
+func (lb *Leaderboard) Incr(member string, delta int64) (int64, error) {
+	var score int64
+	err := lb.with(func(c *Client, board string) error {
+		n, err := c.doReturnInt("zincr", board, member, -delta)
+		score = -n
+		return err
+	})
+	return score, err
+}
+
+// Remove deletes member from the board.
+func (lb *Leaderboard) Remove(member string) error {
+	return lb.with(func(c *Client, board string) error {
+		_, err := c.Zdel(board, member)
+		return err
+	})
+}
+
+// Size returns the number of members ranked.
+func (lb *Leaderboard) Size() (int64, error) {
+	var n int64
+	err := lb.with(func(c *Client, board string) error {
+		var err error
+		n, err = c.Zsize(board)
+		return err
+	})
+	return n, err
+}
+
+// Rank returns the rank of member, or nil if member is not ranked.
+func (lb *Leaderboard) Rank(member string) (*Rank, error) {
+	var rank *Rank
+	err := lb.with(func(c *Client, board string) error {
+		var err error
+		rank, err = lb.rank(c, board, member)
+		return err
+	})
+	return rank, err
+}
+
+func (lb *Leaderboard) rank(c *Client, board, member string) (*Rank, error) {
+	score, err := c.Zget(board, member)
+	if err != nil {
+		if err.Error() == "not_found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	n, err := c.Zrank(board, member)
+	if err != nil {
+		if err.Error() == "not_found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	return &Rank{Member: member, Score: -score, Rank: n + 1}, nil
+}
+
+// Top returns the n members ranked highest.
+func (lb *Leaderboard) Top(n int) ([]Rank, error) {
+	return lb.Range(0, n)
+}
+
+// Page returns the page-th page of the ranks, starting at 0, of size members.
+// Caution: it's slow for large pages, like Zrange.
+func (lb *Leaderboard) Page(page, size int) ([]Rank, error) {
+	return lb.Range(page*size, size)
+}
+
+// Range returns limit members from the offset-th rank, starting at 0.
+func (lb *Leaderboard) Range(offset, limit int) ([]Rank, error) {
+	var ranks []Rank
+	err := lb.with(func(c *Client, board string) error {
+		var err error
+		ranks, err = lb.ranks(c, board, offset, limit)
+		return err
+	})
+	return ranks, err
+}
+
+func (lb *Leaderboard) ranks(c *Client, board string, offset, limit int) ([]Rank, error) {
+	resp, err := c.doReturnRange("zrange", board, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	members, err := toMembers(newMap(resp))
+	if err != nil {
+		return nil, err
+	}
+	ranks := make([]Rank, len(members))
+	for i, m := range members {
+		ranks[i] = Rank{Member: m.Key, Score: -m.Score, Rank: int64(offset + i + 1)}
+	}
+	return ranks, nil
+}
+
+// Around returns member and the n members ranked right above and below it,
+// or nil if member is not ranked.
+func (lb *Leaderboard) Around(member string, n int) ([]Rank, error) {
+	var ranks []Rank
+	err := lb.with(func(c *Client, board string) error {
+		rank, err := lb.rank(c, board, member)
+		if err != nil || rank == nil {
+			return err
+		}
+		offset := int(rank.Rank-1) - n
+		if offset < 0 {
+			offset = 0
+		}
+		ranks, err = lb.ranks(c, board, offset, int(rank.Rank)+n-offset)
+		return err
+	})
+	return ranks, err
+}
@@ -0,0 +1,149 @@
+package ssdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardBoard(t *testing.T) {
+	at := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	tests := []struct {
+		opts  *LeaderboardOptions
+		board string
+	}{
+		{nil, "lb"},
+		{&LeaderboardOptions{Period: Daily}, "lb:20240101"},
+		{&LeaderboardOptions{Period: Daily, Location: time.FixedZone("", -2*3600)}, "lb:20231231"},
+		{&LeaderboardOptions{Period: Weekly}, "lb:2024w01"},
+		{&LeaderboardOptions{Period: Weekly, Location: time.FixedZone("", -2*3600)}, "lb:2023w52"},
+	}
+	for _, tt := range tests {
+		board := NewLeaderboard(nil, "lb", tt.opts).Board(at)
+		if board != tt.board {
+			t.Fatalf("Board failed, expected:%v, got:%v\n", tt.board, board)
+		}
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "leaderboard_test"
+	lb := NewLeaderboard(p, name, nil)
+	defer func() {
+		c := p.Get()
+		c.Zclear(name)
+		p.Release(c)
+	}()
+
+	for member, score := range map[string]int64{"a": 10, "b": 30, "c": 20, "d": 20, "e": 5} {
+		_, err := lb.Submit(member, score)
+		if err != nil {
+			t.Fatalf("Submit failed, err:%v\n", err)
+		}
+	}
+	best, err := lb.Submit("b", 25)
+	if err != nil || best != 30 {
+		t.Fatalf("Submit failed, expected:30, got:%v, err:%v\n", best, err)
+	}
+	score, err := lb.Incr("e", 10)
+	if err != nil || score != 15 {
+		t.Fatalf("Incr failed, expected:15, got:%v, err:%v\n", score, err)
+	}
+
+	top, err := lb.Top(3)
+	expected := []Rank{{"b", 30, 1}, {"c", 20, 2}, {"d", 20, 3}}
+	if err != nil || !reflect.DeepEqual(top, expected) {
+		t.Fatalf("Top failed, expected:%v, got:%v, err:%v\n", expected, top, err)
+	}
+	around, err := lb.Around("d", 1)
+	expected = []Rank{{"c", 20, 2}, {"d", 20, 3}, {"e", 15, 4}}
+	if err != nil || !reflect.DeepEqual(around, expected) {
+		t.Fatalf("Around failed, expected:%v, got:%v, err:%v\n", expected, around, err)
+	}
+	page, err := lb.Page(2, 2)
+	expected = []Rank{{"a", 10, 5}}
+	if err != nil || !reflect.DeepEqual(page, expected) {
+		t.Fatalf("Page failed, expected:%v, got:%v, err:%v\n", expected, page, err)
+	}
+	rank, err := lb.Rank("x")
+	if err != nil || rank != nil {
+		t.Fatalf("Rank failed, expected nil, got:%v, err:%v\n", rank, err)
+	}
+}
+
+func TestLeaderboardIsBoard(t *testing.T) {
+	daily := NewLeaderboard(nil, "lb", &LeaderboardOptions{Period: Daily})
+	weekly := NewLeaderboard(nil, "lb", &LeaderboardOptions{Period: Weekly})
+	tests := []struct {
+		lb    *Leaderboard
+		name  string
+		board bool
+	}{
+		{daily, "lb:20240131", true},
+		{daily, "lb:2024013", false},
+		{daily, "lb:20240131:x", false},
+		{daily, "lb:archive", false},
+		{daily, "lbx:20240131", false},
+		{weekly, "lb:2024w05", true},
+		{weekly, "lb:2024w60", false},
+		{weekly, "lb:20240131", false},
+	}
+	for _, tt := range tests {
+		if got := tt.lb.isBoard(tt.name); got != tt.board {
+			t.Fatalf("isBoard(%q) failed, expected:%v, got:%v\n", tt.name, tt.board, got)
+		}
+	}
+}
+
+func TestLeaderboardRollover(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "leaderboard_rollover_test"
+	lb := NewLeaderboard(p, name, &LeaderboardOptions{Period: Daily, Keep: 1})
+	now := time.Now()
+	boards := []string{lb.Board(now.AddDate(0, 0, -3)), lb.Board(now.AddDate(0, 0, -2)),
+		lb.Board(now.AddDate(0, 0, -1)), name + ":0archive"}
+	c := p.Get()
+	for _, board := range boards {
+		_, err = c.Zset(board, "a", -1)
+	}
+	p.Release(c)
+	if err != nil {
+		t.Fatalf("Zset failed, err:%v\n", err)
+	}
+	defer func() {
+		c := p.Get()
+		for _, board := range append(boards, lb.Board(now)) {
+			c.Zclear(board)
+		}
+		p.Release(c)
+	}()
+
+	if _, err = lb.Submit("b", 1); err != nil {
+		t.Fatalf("Submit failed, err:%v\n", err)
+	}
+	c = p.Get()
+	defer p.Release(c)
+	for i, board := range boards {
+		n, err := c.Zsize(board)
+		if err != nil {
+			t.Fatalf("Zsize failed, err:%v\n", err)
+		}
+		// the 2 boards before yesterday are cleared.
+		expected := int64(1)
+		if i < 2 {
+			expected = 0
+		}
+		if n != expected {
+			t.Fatalf("Submit failed, expected %v with size %v, got:%v\n", board, expected, n)
+		}
+	}
+}
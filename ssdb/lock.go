@@ -0,0 +1,214 @@
+package ssdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mrand "math/rand"
+	"sync"
+	"time"
+)
+
+// ErrLockNotHeld is returned by Unlock if the lock is not held by the owner any more.
+var ErrLockNotHeld = errors.New("lock not held")
+
+const (
+	lockMinBackoff = 10 * time.Millisecond
+	lockMaxBackoff = time.Second
+)
+
+// Locker creates distributed locks stored as kv keys, by setnx and expire.
+// The value of the key is a random token of the owner, so only the owner can release it.
+// Notice: ssdb has no compare-and-delete, so Unlock checks the token and deletes the key in
+// two steps, the lock may be released by the owner right after it expired and taken by another.
+type Locker struct {
+	r Runner
+}
+
+// NewLocker returns a Locker on a Client or a Pool.
+// The leases are extended in background goroutines, so a Client used by a Locker
+// should not be used by others, except through Client.Run.
+func NewLocker(r Runner) *Locker {
+	return &Locker{r: r}
+}
+
+// Lock acquires the lock of name, retrying with backoff until ctx is done.
+// The lease lasts ttl(in seconds, at least 1 second), and it's extended automatically
+// until Unlock is called. If the lease is lost, the channel returned by Lost is closed.
+func (l *Locker) Lock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	backoff := lockMinBackoff
+	for {
+		ok, err := l.tryLock(name, token, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			lk := &Lock{
+				locker: l,
+				name:   name,
+				token:  token,
+				ttl:    ttl,
+				stop:   make(chan struct{}),
+				lost:   make(chan struct{}),
+			}
+			go lk.keepAlive()
+			return lk, nil
+		}
+
+		// full jitter, sleep in [backoff/2, backoff).
+		d := backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)))
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > lockMaxBackoff {
+			backoff = lockMaxBackoff
+		}
+	}
+}
+
+func (l *Locker) tryLock(name, token string, ttl time.Duration) (ok bool, err error) {
+	secs := int64(ttl / time.Second)
+	err = l.r.Run(func(c *Client) error {
+		n, err := c.Setnx(name, token)
+		if err != nil {
+			return err
+		}
+		if n == 1 {
+			ok = true
+			_, err = c.Expire(name, secs)
+			return err
+		}
+
+		// the owner may crash between setnx and expire, leaving the lock forever.
+		left, err := c.Ttl(name)
+		if err != nil {
+			return err
+		}
+		if left == -1 {
+			_, err = c.Expire(name, secs)
+		}
+		return err
+	})
+	return ok, err
+}
+
+// Lock is a distributed lock held.
+type Lock struct {
+	locker *Locker
+	name   string
+	token  string
+	ttl    time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	lostOnce sync.Once
+	lost     chan struct{}
+}
+
+// Name returns the name of the lock.
+func (lk *Lock) Name() string {
+	return lk.name
+}
+
+// Token returns the random token of the owner.
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// Lost returns a channel closed when the lease is lost, because it expired or was taken by others.
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Unlock stops extending the lease, and releases the lock if it's still held by the owner,
+// otherwise ErrLockNotHeld is returned.
+func (lk *Lock) Unlock() error {
+	lk.stopOnce.Do(func() { close(lk.stop) })
+
+	return lk.locker.r.Run(func(c *Client) error {
+		held, err := lk.held(c)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return ErrLockNotHeld
+		}
+		return c.Del(lk.name)
+	})
+}
+
+// held reports whether the lock is still held by the owner.
+func (lk *Lock) held(c *Client) (bool, error) {
+	v, err := c.Get(lk.name)
+	if err != nil {
+		if err.Error() == "not_found" {
+			return false, nil
+		}
+		return false, err
+	}
+	return v == lk.token, nil
+}
+
+// keepAlive extends the lease every third of ttl, until the lock is released or lost.
+func (lk *Lock) keepAlive() {
+	ticker := time.NewTicker(lk.ttl / 3)
+	defer ticker.Stop()
+
+	secs := int64(lk.ttl / time.Second)
+	extended := time.Now()
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+
+		var held bool
+		err := lk.locker.r.Run(func(c *Client) error {
+			var err error
+			held, err = lk.held(c)
+			if err != nil || !held {
+				return err
+			}
+			_, err = c.Expire(lk.name, secs)
+			return err
+		})
+		switch {
+		case err == nil && held:
+			extended = time.Now()
+			continue
+		case err != nil && time.Since(extended) < lk.ttl:
+			// retry on the next tick, the lease is not expired yet.
+			continue
+		}
+		select {
+		case <-lk.stop:
+			// released by Unlock meanwhile.
+		default:
+			lk.lostOnce.Do(func() { close(lk.lost) })
+		}
+		return
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,70 @@
+package ssdb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "lock_test"
+	locker := NewLocker(p)
+
+	lk, err := locker.Lock(context.Background(), name, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Lock failed, err:%v\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = locker.Lock(ctx, name, 2*time.Second)
+	cancel()
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Lock failed, expected:%v, got:%v\n", context.DeadlineExceeded, err)
+	}
+
+	// the lease is extended beyond ttl.
+	time.Sleep(3 * time.Second)
+	select {
+	case <-lk.Lost():
+		t.Fatalf("Lost failed, lease lost while held\n")
+	default:
+	}
+
+	err = lk.Unlock()
+	if err != nil {
+		t.Fatalf("Unlock failed, err:%v\n", err)
+	}
+	err = lk.Unlock()
+	if err != ErrLockNotHeld {
+		t.Fatalf("Unlock failed, expected:%v, got:%v\n", ErrLockNotHeld, err)
+	}
+
+	lk, err = locker.Lock(context.Background(), name, time.Second)
+	if err != nil {
+		t.Fatalf("Lock failed, err:%v\n", err)
+	}
+
+	// someone else takes the lock.
+	c := p.Get()
+	err = c.Set(name, "other")
+	p.Release(c)
+	if err != nil {
+		t.Fatalf("Set failed, err:%v\n", err)
+	}
+
+	select {
+	case <-lk.Lost():
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Lost failed, lease not reported lost\n")
+	}
+	lk.Unlock()
+
+	c = p.Get()
+	c.Del(name)
+	p.Release(c)
+}
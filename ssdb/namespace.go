@@ -0,0 +1,183 @@
+package ssdb
+
+import (
+	"strings"
+)
+
+// How the arguments of a command are namespaced.
+const (
+	// nsOne prefixes the argument at from only.
+	nsOne = iota
+	// nsAll prefixes all the arguments from from.
+	nsAll
+	// nsPairs prefixes the keys of key-value pairs from from.
+	nsPairs
+	// nsRange clamps the range (start, end] at from and from+1 to the namespace.
+	nsRange
+	// nsRrange clamps the reverse range at from and from+1 to the namespace.
+	nsRrange
+)
+
+// How the response of a command is namespaced.
+const (
+	nsRespNone = iota
+	// nsRespNames strips the prefix of all the items, and drops those not in the namespace.
+	nsRespNames
+	// nsRespPairs strips the prefix of the keys of key-value pairs, and drops those not in the namespace.
+	nsRespPairs
+)
+
+type nsRule struct {
+	args int
+	from int
+	resp int
+}
+
+// nsRules records the commands taking keys of kv, or names of hashmaps, zsets and queues.
+// The keys inside hashmaps and zsets are not prefixed, they are private to the namespaced names.
+var nsRules = map[string]nsRule{}
+
+func init() {
+	for _, cmd := range []string{
+		// kv
+		"get", "set", "setx", "setnx", "getset", "del", "exists", "expire", "ttl", "incr", "decr",
+		"setbit", "getbit", "countbit", "bitcount", "substr", "strlen",
+		// hashmap
+		"hset", "hget", "hdel", "hincr", "hexists", "hsize", "hkeys", "hgetall", "hscan", "hrscan", "hclear",
+		"multi_hset", "multi_hget", "multi_hdel", "multi_hexists",
+		// zset
+		"zset", "zget", "zdel", "zincr", "zexists", "zsize", "zkeys", "zscan", "zrscan", "zrank", "zrrank",
+		"zrange", "zrrange", "zclear", "zcount", "zsum", "zavg", "zremrangebyrank", "zremrangebyscore",
+		"zpop_front", "zpop_back", "multi_zset", "multi_zget", "multi_zdel", "multi_zexists",
+		// queue
+		"qpush", "qpush_front", "qpush_back", "qpop", "qpop_front", "qpop_back", "qfront", "qback",
+		"qsize", "qclear", "qget", "qset", "qrange", "qslice", "qtrim_front", "qtrim_back",
+	} {
+		nsRules[cmd] = nsRule{args: nsOne, from: 1}
+	}
+
+	nsRules["multi_get"] = nsRule{args: nsAll, from: 1, resp: nsRespPairs}
+	nsRules["multi_del"] = nsRule{args: nsAll, from: 1}
+	nsRules["multi_exists"] = nsRule{args: nsAll, from: 1, resp: nsRespPairs}
+	nsRules["multi_set"] = nsRule{args: nsPairs, from: 1}
+	nsRules["multi_hsize"] = nsRule{args: nsAll, from: 1, resp: nsRespPairs}
+	nsRules["multi_zsize"] = nsRule{args: nsAll, from: 1, resp: nsRespPairs}
+
+	for _, cmd := range []string{"keys", "hlist", "zlist", "qlist"} {
+		nsRules[cmd] = nsRule{args: nsRange, from: 1, resp: nsRespNames}
+	}
+	for _, cmd := range []string{"rkeys", "hrlist", "zrlist", "qrlist"} {
+		nsRules[cmd] = nsRule{args: nsRrange, from: 1, resp: nsRespNames}
+	}
+	nsRules["scan"] = nsRule{args: nsRange, from: 1, resp: nsRespPairs}
+	nsRules["rscan"] = nsRule{args: nsRrange, from: 1, resp: nsRespPairs}
+}
+
+// SetNamespace makes the Client prefix the keys of kv, and the names of hashmaps, zsets and queues
+// with prefix on every command, like "svc1:". The prefix is stripped from the results of Keys, Scan,
+// Hlist, Zlist, Qlist etc., and their ranges are clamped to the namespace, so the data of other
+// namespaces is never returned. FlushDB only deletes the data in the namespace.
+// An empty prefix disables namespace.
+func (c *Client) SetNamespace(prefix string) {
+	c.namespace = prefix
+}
+
+// Namespace returns the prefix set by SetNamespace.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// namespaceArgs returns the arguments with keys and names prefixed.
+func (c *Client) namespaceArgs(args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	cmd, _ := args[0].(string)
+	rule, ok := nsRules[cmd]
+	if !ok {
+		return args
+	}
+
+	args = flattenArgs(args)
+	prefix := c.namespace
+	switch rule.args {
+	case nsOne:
+		if rule.from < len(args) {
+			args[rule.from] = c.prefixed(args[rule.from])
+		}
+	case nsAll:
+		for i := rule.from; i < len(args); i++ {
+			args[i] = c.prefixed(args[i])
+		}
+	case nsPairs:
+		for i := rule.from; i < len(args); i += 2 {
+			args[i] = c.prefixed(args[i])
+		}
+	case nsRange, nsRrange:
+		if rule.from+1 >= len(args) {
+			break
+		}
+		lower, upper := prefix, prefixEnd(prefix)
+		if rule.args == nsRrange {
+			lower, upper = upper, lower
+		}
+		args[rule.from] = c.clamp(args[rule.from], lower)
+		args[rule.from+1] = c.clamp(args[rule.from+1], upper)
+	}
+	return args
+}
+
+func (c *Client) prefixed(arg interface{}) interface{} {
+	data, err := atomBytes(arg)
+	if err != nil {
+		// leave it to formatData to report the error.
+		return arg
+	}
+	return c.namespace + string(data)
+}
+
+// clamp prefixes the range bound, or replaces the empty bound for no limit with bound.
+func (c *Client) clamp(arg interface{}, bound string) interface{} {
+	if s, ok := arg.(string); ok && s == "" {
+		return bound
+	}
+	return c.prefixed(arg)
+}
+
+// prefixEnd returns the smallest string greater than all the strings with the prefix,
+// or empty string for no limit if there is not.
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+// namespaceResp strips the prefix in the response, dropping the items not in the namespace.
+func (c *Client) namespaceResp(cmd interface{}, resp []string) []string {
+	name, _ := cmd.(string)
+	rule, ok := nsRules[name]
+	if !ok || rule.resp == nsRespNone || len(resp) == 0 || resp[0] != "ok" {
+		return resp
+	}
+
+	step := 1
+	if rule.resp == nsRespPairs {
+		step = 2
+	}
+	out := resp[:1]
+	for i := 1; i+step-1 < len(resp); i += step {
+		if !strings.HasPrefix(resp[i], c.namespace) {
+			continue
+		}
+		out = append(out, resp[i][len(c.namespace):])
+		if step == 2 {
+			out = append(out, resp[i+1])
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package ssdb
+
+import (
+	"testing"
+)
+
+func TestNamespaceArgs(t *testing.T) {
+	c := &Client{}
+	c.SetNamespace("ns:")
+
+	args := c.namespaceArgs([]interface{}{"hset", "h", "k", "v"})
+	if args[1] != "ns:h" || args[2] != "k" {
+		t.Fatalf("namespaceArgs failed, got:%v\n", args)
+	}
+
+	args = c.namespaceArgs([]interface{}{"multi_set", []interface{}{"a", 1, "b", 2}})
+	if len(args) != 5 || args[1] != "ns:a" || args[2] != 1 || args[3] != "ns:b" {
+		t.Fatalf("namespaceArgs failed, got:%v\n", args)
+	}
+
+	args = c.namespaceArgs([]interface{}{"scan", "", "", 10})
+	if args[1] != "ns:" || args[2] != "ns;" {
+		t.Fatalf("namespaceArgs failed, got:%v\n", args)
+	}
+
+	args = c.namespaceArgs([]interface{}{"rkeys", "", "a", 10})
+	if args[1] != "ns;" || args[2] != "ns:a" {
+		t.Fatalf("namespaceArgs failed, got:%v\n", args)
+	}
+
+	args = c.namespaceArgs([]interface{}{"info", "cmd"})
+	if args[1] != "cmd" {
+		t.Fatalf("namespaceArgs failed, got:%v\n", args)
+	}
+}
+
+func TestNamespaceResp(t *testing.T) {
+	c := &Client{}
+	c.SetNamespace("ns:")
+
+	resp := c.namespaceResp("scan", []string{"ok", "ns:a", "1", "ns;", "2"})
+	if len(resp) != 3 || resp[1] != "a" || resp[2] != "1" {
+		t.Fatalf("namespaceResp failed, got:%v\n", resp)
+	}
+
+	resp = c.namespaceResp("hlist", []string{"ok", "ns:h1", "ns:h2"})
+	if len(resp) != 3 || resp[1] != "h1" || resp[2] != "h2" {
+		t.Fatalf("namespaceResp failed, got:%v\n", resp)
+	}
+
+	resp = c.namespaceResp("get", []string{"ok", "ns:v"})
+	if resp[1] != "ns:v" {
+		t.Fatalf("namespaceResp failed, got:%v\n", resp)
+	}
+
+	if end := prefixEnd("a\xff"); end != "b" {
+		t.Fatalf("prefixEnd failed, expected:%q, got:%q\n", "b", end)
+	}
+	if end := prefixEnd("\xff"); end != "" {
+		t.Fatalf("prefixEnd failed, expected:%q, got:%q\n", "", end)
+	}
+}
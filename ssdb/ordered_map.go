@@ -1,5 +1,14 @@
 package ssdb
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strconv"
+	"sync"
+)
+
 type OrderedMap interface {
 	Keys() []string
 	Values() []string
@@ -7,15 +16,33 @@ type OrderedMap interface {
 	Index(int) (key string, value string)
 	Lookup(key string) (value string, exists bool)
 
+	// Typed accessors, an error is returned if the key is not existed or the value can't be parsed.
+	Int64(key string) (int64, error)
+	Float64(key string) (float64, error)
+
+	// ToMap converts to a map, the order is lost.
+	ToMap() map[string]string
+
 	// For iteration.
+	// Next and Reset share the cursor in the map, use Iter or All when there are concurrent readers.
 	Next() (key string, value string, end bool)
 	Reset()
+	// Iter returns a new cursor on the map.
+	Iter() *MapIterator
+	// All returns the pairs in order for range-over-func.
+	All() iter.Seq2[string, string]
+
+	json.Marshaler
 }
 
 type orderedMap struct {
 	keys   []string
 	values []string
 	iter   int
+
+	// index is built on the first Lookup.
+	indexOnce sync.Once
+	index     map[string]int
 }
 
 func newMap(s []string) OrderedMap {
@@ -45,13 +72,45 @@ func (om *orderedMap) Index(i int) (key string, value string) {
 }
 
 func (om *orderedMap) Lookup(key string) (value string, exists bool) {
-	// TODO : it has a bad lookup algo, using a map instead?
-	for i, k := range om.keys {
-		if key == k {
-			return om.values[i], true
+	om.indexOnce.Do(func() {
+		om.index = make(map[string]int, len(om.keys))
+		for i, k := range om.keys {
+			// keep the first one for duplicated keys.
+			if _, ok := om.index[k]; !ok {
+				om.index[k] = i
+			}
 		}
+	})
+	i, ok := om.index[key]
+	if !ok {
+		return "", false
+	}
+	return om.values[i], true
+}
+
+func (om *orderedMap) Int64(key string) (int64, error) {
+	v, ok := om.Lookup(key)
+	if !ok {
+		return 0, fmt.Errorf("key %q not found", key)
 	}
-	return "", false
+	return strconv.ParseInt(v, 10, 64)
+}
+
+func (om *orderedMap) Float64(key string) (float64, error) {
+	v, ok := om.Lookup(key)
+	if !ok {
+		return 0, fmt.Errorf("key %q not found", key)
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+func (om *orderedMap) ToMap() map[string]string {
+	m := make(map[string]string, len(om.keys))
+	for i := len(om.keys) - 1; i >= 0; i-- {
+		// the first one wins for duplicated keys, the same as Lookup.
+		m[om.keys[i]] = om.values[i]
+	}
+	return m
 }
 
 func (om *orderedMap) Next() (key string, value string, end bool) {
@@ -66,3 +125,57 @@ func (om *orderedMap) Next() (key string, value string, end bool) {
 func (om *orderedMap) Reset() {
 	om.iter = 0
 }
+
+func (om *orderedMap) Iter() *MapIterator {
+	return &MapIterator{om: om}
+}
+
+func (om *orderedMap) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for i := range om.keys {
+			if !yield(om.keys[i], om.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON encodes the map as a JSON object, keeping the order of keys.
+func (om *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := range om.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(om.keys[i])
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(om.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MapIterator is a cursor on an OrderedMap, every reader should get its own by OrderedMap.Iter.
+type MapIterator struct {
+	om  *orderedMap
+	pos int
+}
+
+// Next returns the next pair, end is true if there are no more pairs.
+func (it *MapIterator) Next() (key string, value string, end bool) {
+	if it.pos >= len(it.om.keys) {
+		return "", "", true
+	}
+	i := it.pos
+	it.pos++
+	return it.om.keys[i], it.om.values[i], false
+}
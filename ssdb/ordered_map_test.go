@@ -0,0 +1,68 @@
+package ssdb
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+	om := newMap([]string{"b", "2", "a", "1.5", "b", "3", "c", "x"})
+
+	v, ok := om.Lookup("b")
+	if !ok || v != "2" {
+		t.Fatalf("Lookup failed, expected:%v, got:%v %v\n", "2", v, ok)
+	}
+	if _, ok = om.Lookup("d"); ok {
+		t.Fatalf("Lookup failed, d should not exist\n")
+	}
+
+	n, err := om.Int64("b")
+	if err != nil || n != 2 {
+		t.Fatalf("Int64 failed, expected:%v, got:%v %v\n", 2, n, err)
+	}
+	if _, err = om.Int64("c"); err == nil {
+		t.Fatalf("Int64 failed, expected error for bad value\n")
+	}
+	if _, err = om.Int64("d"); err == nil {
+		t.Fatalf("Int64 failed, expected error for absent key\n")
+	}
+
+	f, err := om.Float64("a")
+	if err != nil || f != 1.5 {
+		t.Fatalf("Float64 failed, expected:%v, got:%v %v\n", 1.5, f, err)
+	}
+
+	m := om.ToMap()
+	if len(m) != 3 || m["b"] != "2" {
+		t.Fatalf("ToMap failed, got:%v\n", m)
+	}
+
+	data, err := json.Marshal(om)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed, err:%v\n", err)
+	}
+	expected := `{"b":"2","a":"1.5","b":"3","c":"x"}`
+	if string(data) != expected {
+		t.Fatalf("MarshalJSON failed, expected:%v, got:%v\n", expected, string(data))
+	}
+
+	it1, it2 := om.Iter(), om.Iter()
+	k1, _, _ := it1.Next()
+	k1, _, _ = it1.Next()
+	k2, _, _ := it2.Next()
+	if k1 != "a" || k2 != "b" {
+		t.Fatalf("Iter failed, expected:%v %v, got:%v %v\n", "a", "b", k1, k2)
+	}
+
+	count := 0
+	for k, v := range om.All() {
+		ek, ev := om.Index(count)
+		if k != ek || v != ev {
+			t.Fatalf("All failed, expected:%v=%v, got:%v=%v\n", ek, ev, k, v)
+		}
+		count++
+	}
+	if count != om.Length() {
+		t.Fatalf("All failed, expected:%v, got:%v\n", om.Length(), count)
+	}
+}
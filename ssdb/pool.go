@@ -1,6 +1,7 @@
 package ssdb
 
 import (
+	"errors"
 	"fmt"
 	"sync/atomic"
 )
@@ -34,6 +35,14 @@ type Pool struct {
 	active int32
 	// Pool is closed or not
 	opened bool
+	// Codec for the *Object methods of the Client connections.
+	codec Codec
+	// Compression threshold of the Client connections, 0 to disable.
+	compressThreshold int
+	// Namespace of the Client connections, empty for no namespace.
+	namespace string
+	// Encryption of the Client connections, nil to disable.
+	encryptor *encryptor
 }
 
 // Open creates the channel for Client connections.
@@ -64,6 +73,10 @@ func (p *Pool) Get() (c *Client) {
 	for p.opened {
 		select {
 		case c = <-p.clients:
+			c.codec = p.codec
+			c.compressThreshold = p.compressThreshold
+			c.namespace = p.namespace
+			c.encryptor = p.encryptor
 			return c
 		default:
 			p.gen()
@@ -87,6 +100,46 @@ func (p *Pool) Release(c *Client) {
 	}
 }
 
+// SetCodec sets the codec used by the *Object methods of the Client connections returned by Get.
+func (p *Pool) SetCodec(codec Codec) {
+	p.codec = codec
+}
+
+// SetCompression sets the compression threshold of the Client connections returned by Get,
+// see Client.SetCompression.
+func (p *Pool) SetCompression(threshold int) {
+	p.compressThreshold = threshold
+}
+
+// SetNamespace sets the namespace of the Client connections returned by Get, see Client.SetNamespace.
+func (p *Pool) SetNamespace(prefix string) {
+	p.namespace = prefix
+}
+
+// SetEncryption sets the encryption of the Client connections returned by Get, see Client.SetEncryption.
+func (p *Pool) SetEncryption(opts *EncryptOptions) error {
+	if opts == nil {
+		p.encryptor = nil
+		return nil
+	}
+	e, err := newEncryptor(opts)
+	if err != nil {
+		return err
+	}
+	p.encryptor = e
+	return nil
+}
+
+// Run runs f with a free Client connection, and releases it after f returns.
+func (p *Pool) Run(f func(c *Client) error) error {
+	c := p.Get()
+	if c == nil {
+		return errors.New("pool closed")
+	}
+	defer p.Release(c)
+	return f(c)
+}
+
 // ServerAddress returns the server ip and port.
 func (p *Pool) ServerAddress() string {
 	return fmt.Sprintf("%s:%d", p.ip, p.port)
@@ -0,0 +1,161 @@
+package ssdb
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// priorityShift is the bits of the sequence in the score of an item.
+	priorityShift = 40
+	// MaxPriority and MinPriority bound the priorities of a PriorityQueue.
+	MaxPriority = 1<<(62-priorityShift) - 1
+	MinPriority = -MaxPriority
+)
+
+// PriorityQueue pops the items of the highest priority first, and the items of the same priority
+// in the order they are pushed. The items are indexed in the zset name, scored by
+// -priority<<40 + seq, where seq is taken by incr on the key name+":seq", so zpop_front pops
+// the next item. The values are kept in the hashmap name+":items" keyed by seq.
+type PriorityQueue struct {
+	r    Runner
+	name string
+}
+
+// PriorityItem is an item of a PriorityQueue.
+type PriorityItem struct {
+	Value    string
+	Priority int64
+}
+
+// NewPriorityQueue returns a PriorityQueue on a Client or a Pool.
+func NewPriorityQueue(r Runner, name string) *PriorityQueue {
+	return &PriorityQueue{r: r, name: name}
+}
+
+// Push adds a value of priority, which is in [MinPriority, MaxPriority].
+func (q *PriorityQueue) Push(value interface{}, priority int64) error {
+	if priority < MinPriority || priority > MaxPriority {
+		return fmt.Errorf("priority %d out of range [%d, %d]", priority, int64(MinPriority), int64(MaxPriority))
+	}
+	return q.r.Run(func(c *Client) error {
+		seq, err := c.Incr(q.name+":seq", 1)
+		if err != nil {
+			return err
+		}
+		seq &= 1<<priorityShift - 1
+		key := strconv.FormatInt(seq, 10)
+		_, err = c.Hset(q.name+":items", key, value)
+		if err != nil {
+			return err
+		}
+		_, err = c.Zset(q.name, key, -priority<<priorityShift+seq)
+		return err
+	})
+}
+
+// Pop removes and returns the next item, or nil if the queue is empty.
+func (q *PriorityQueue) Pop() (*PriorityItem, error) {
+	items, err := q.PopN(1)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// PopN removes and returns at most n next items, by zpop_front.
+func (q *PriorityQueue) PopN(n int) ([]PriorityItem, error) {
+	var items []PriorityItem
+	err := q.r.Run(func(c *Client) error {
+		m, err := c.Zpopfront(q.name, n)
+		if err != nil {
+			if err.Error() == "no data found" {
+				return nil
+			}
+			return err
+		}
+		items, err = q.items(c, m)
+		if err != nil {
+			return err
+		}
+		_, err = c.MultiHdel(q.name+":items", m.Keys())
+		return err
+	})
+	return items, err
+}
+
+// Peek returns the next item without removing it, or nil if the queue is empty.
+func (q *PriorityQueue) Peek() (*PriorityItem, error) {
+	items, err := q.PeekN(1)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// PeekN returns at most n next items without removing them.
+func (q *PriorityQueue) PeekN(n int) ([]PriorityItem, error) {
+	var items []PriorityItem
+	err := q.r.Run(func(c *Client) error {
+		resp, err := c.doReturnRange("zrange", q.name, 0, n)
+		if err != nil || len(resp) == 0 {
+			return err
+		}
+		items, err = q.items(c, newMap(resp))
+		return err
+	})
+	return items, err
+}
+
+// Size returns the number of items.
+func (q *PriorityQueue) Size() (int64, error) {
+	var n int64
+	err := q.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Zsize(q.name)
+		return err
+	})
+	return n, err
+}
+
+// Clear deletes all the items.
+func (q *PriorityQueue) Clear() error {
+	return q.r.Run(func(c *Client) error {
+		if _, err := c.Zclear(q.name); err != nil {
+			return err
+		}
+		_, err := c.Hclear(q.name + ":items")
+		return err
+	})
+}
+
+// items returns the items of the seq-score pairs in order, the items without values are skipped.
+func (q *PriorityQueue) items(c *Client, m OrderedMap) ([]PriorityItem, error) {
+	resp, err := c.unpackPairs(c.doReturnRange("multi_hget", q.name+":items", m.Keys()))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(resp)/2)
+	for i := 0; i+1 < len(resp); i += 2 {
+		values[resp[i]] = resp[i+1]
+	}
+
+	members, err := toMembers(m)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]PriorityItem, 0, len(members))
+	for _, mem := range members {
+		v, ok := values[mem.Key]
+		if !ok {
+			continue
+		}
+		items = append(items, PriorityItem{Value: v, Priority: priorityOf(mem.Score)})
+	}
+	return items, nil
+}
+
+// priorityOf returns the priority of a score, the sequence in the low bits is always positive.
+func priorityOf(score int64) int64 {
+	return -(score >> priorityShift)
+}
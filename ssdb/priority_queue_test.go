@@ -0,0 +1,67 @@
+package ssdb
+
+import (
+	"testing"
+)
+
+func TestPriorityOf(t *testing.T) {
+	for _, p := range []int64{0, 1, -1, 100, -100, MaxPriority, MinPriority} {
+		for _, seq := range []int64{0, 1, 1<<priorityShift - 1} {
+			if got := priorityOf(-p<<priorityShift + seq); got != p {
+				t.Fatalf("priorityOf failed, expected:%v, got:%v\n", p, got)
+			}
+		}
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewPriorityQueue(p, "priority_queue_test")
+	defer func() {
+		q.Clear()
+		c := p.Get()
+		c.Del("priority_queue_test:seq")
+		p.Release(c)
+	}()
+
+	for _, item := range []PriorityItem{{"low1", -1}, {"high1", 5}, {"mid", 0}, {"high2", 5}, {"low2", -1}} {
+		if err := q.Push(item.Value, item.Priority); err != nil {
+			t.Fatalf("Push failed, err:%v\n", err)
+		}
+	}
+	if err := q.Push("x", MaxPriority+1); err == nil {
+		t.Fatalf("Push failed, expected an error for priority out of range\n")
+	}
+
+	size, err := q.Size()
+	if err != nil || size != 5 {
+		t.Fatalf("Size failed, expected:5, got:%v, err:%v\n", size, err)
+	}
+	item, err := q.Peek()
+	if err != nil || item == nil || item.Value != "high1" || item.Priority != 5 {
+		t.Fatalf("Peek failed, got:%v, err:%v\n", item, err)
+	}
+
+	item, err = q.Pop()
+	if err != nil || item == nil || item.Value != "high1" {
+		t.Fatalf("Pop failed, got:%v, err:%v\n", item, err)
+	}
+	items, err := q.PopN(10)
+	if err != nil || len(items) != 4 {
+		t.Fatalf("PopN failed, got:%v, err:%v\n", items, err)
+	}
+	for i, expected := range []string{"high2", "mid", "low1", "low2"} {
+		if items[i].Value != expected {
+			t.Fatalf("PopN failed, expected:%v, got:%v\n", expected, items[i].Value)
+		}
+	}
+
+	item, err = q.Pop()
+	if err != nil || item != nil {
+		t.Fatalf("Pop failed, expected nil, got:%v, err:%v\n", item, err)
+	}
+}
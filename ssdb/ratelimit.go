@@ -0,0 +1,162 @@
+package ssdb
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the result of a rate limiter check.
+type RateLimit struct {
+	// Allowed reports whether the request is allowed.
+	Allowed bool
+	// Remaining is the number of requests still allowed in the current window.
+	Remaining int64
+	// RetryAfter is how long to wait before the next request may be allowed,
+	// 0 if the request is allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter limits the requests of callers, keyed by caller like a user id or an IP.
+type RateLimiter interface {
+	Allow(caller string) (*RateLimit, error)
+}
+
+// FixedWindowLimiter allows limit requests per caller in every window aligned to the Unix epoch,
+// counted by incr on a kv key of the caller and the window, which expires after the window.
+// It's cheap, but allows bursts of up to 2*limit requests around the window boundaries.
+type FixedWindowLimiter struct {
+	r      Runner
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewFixedWindowLimiter returns a FixedWindowLimiter on a Client or a Pool,
+// the keys of the callers are prefixed with prefix.
+// The window is rounded up to whole seconds, because keys expire in seconds,
+// and a window shorter than 1 second is an error.
+func NewFixedWindowLimiter(r Runner, prefix string, limit int64, window time.Duration) (*FixedWindowLimiter, error) {
+	if window < time.Second {
+		return nil, fmt.Errorf("window %v shorter than 1 second", window)
+	}
+	return &FixedWindowLimiter{
+		r:      r,
+		prefix: prefix,
+		limit:  limit,
+		window: (window + time.Second - 1).Truncate(time.Second),
+	}, nil
+}
+
+// Allow counts a request of the caller, and reports whether it's allowed.
+// Denied requests are counted too.
+func (l *FixedWindowLimiter) Allow(caller string) (*RateLimit, error) {
+	now := time.Now()
+	slot := now.UnixNano() / int64(l.window)
+	reset := time.Unix(0, (slot+1)*int64(l.window))
+	key := l.prefix + caller + ":" + strconv.FormatInt(slot, 10)
+
+	var n int64
+	err := l.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Incr(key, 1)
+		if err != nil {
+			return err
+		}
+		if n == 1 {
+			// keep the key a bit longer than the window, against clock skew among clients.
+			_, err = c.Expire(key, int64(l.window/time.Second)+1)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if n > l.limit {
+		return &RateLimit{RetryAfter: reset.Sub(now)}, nil
+	}
+	return &RateLimit{Allowed: true, Remaining: l.limit - n}, nil
+}
+
+// SlidingWindowLimiter allows limit requests per caller in any window of the duration,
+// logged in a zset of the caller scored by the time of the requests in microseconds.
+// It's exact, but costs a zset entry per allowed request.
+// Notice: zsets never expire, the zset of a caller is emptied on its next request only.
+type SlidingWindowLimiter struct {
+	r      Runner
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindowLimiter returns a SlidingWindowLimiter on a Client or a Pool,
+// the zsets of the callers are prefixed with prefix.
+func NewSlidingWindowLimiter(r Runner, prefix string, limit int64, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		r:      r,
+		prefix: prefix,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow logs a request of the caller, and reports whether it's allowed.
+// The request is logged before counting and removed if denied, so concurrent requests
+// never exceed the limit, though they may deny each other near the limit.
+func (l *SlidingWindowLimiter) Allow(caller string) (*RateLimit, error) {
+	id, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	name := l.prefix + caller
+	now := time.Now().UnixMicro()
+	window := l.window.Microseconds()
+	cutoff := now - window
+
+	res := &RateLimit{}
+	err = l.r.Run(func(c *Client) error {
+		_, err := c.Zremrangebyscore(name, 0, cutoff)
+		if err != nil {
+			return err
+		}
+		_, err = c.Zset(name, id, now)
+		if err != nil {
+			return err
+		}
+		n, err := c.ZcountByScore(name, strconv.FormatInt(cutoff+1, 10), "+inf")
+		if err != nil {
+			return err
+		}
+		if n <= l.limit {
+			res.Allowed = true
+			res.Remaining = l.limit - n
+			return nil
+		}
+
+		_, err = c.Zdel(name, id)
+		if err != nil {
+			return err
+		}
+		// the window slides past the oldest request.
+		resp, err := c.doReturnRange("zrange", name, 0, 1)
+		if err != nil {
+			return err
+		}
+		res.RetryAfter = l.window
+		if len(resp) == 2 {
+			if oldest, err := strconv.ParseInt(resp[1], 10, 64); err == nil {
+				res.RetryAfter = time.Duration(oldest+window-now) * time.Microsecond
+			}
+		}
+		if res.RetryAfter < 0 {
+			res.RetryAfter = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package ssdb
+
+import (
+	"testing"
+	"time"
+)
+
+func testRateLimiter(t *testing.T, l RateLimiter, caller string, limit int64) {
+	for i := int64(1); i <= limit; i++ {
+		res, err := l.Allow(caller)
+		if err != nil {
+			t.Fatalf("Allow failed, err:%v\n", err)
+		}
+		if !res.Allowed || res.Remaining != limit-i {
+			t.Fatalf("Allow failed, expected allowed with %v remaining, got:%+v\n", limit-i, res)
+		}
+	}
+
+	res, err := l.Allow(caller)
+	if err != nil {
+		t.Fatalf("Allow failed, err:%v\n", err)
+	}
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter <= 0 {
+		t.Fatalf("Allow failed, expected denied, got:%+v\n", res)
+	}
+}
+
+func TestFixedWindowLimiter(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// stay in one window.
+	left := time.Until(time.Now().Truncate(time.Minute).Add(time.Minute))
+	if left < 5*time.Second {
+		time.Sleep(left)
+	}
+	l, err := NewFixedWindowLimiter(p, "ratelimit_test:", 5, time.Minute)
+	if err != nil {
+		t.Fatalf("NewFixedWindowLimiter failed, err:%v\n", err)
+	}
+	testRateLimiter(t, l, "fixed", 5)
+}
+
+func TestFixedWindowRounding(t *testing.T) {
+	for _, tt := range []struct{ window, expected time.Duration }{
+		{time.Second, time.Second},
+		{1500 * time.Millisecond, 2 * time.Second},
+		{time.Second + time.Nanosecond, 2 * time.Second},
+		{time.Minute, time.Minute},
+	} {
+		l, err := NewFixedWindowLimiter(nil, "", 1, tt.window)
+		if err != nil {
+			t.Fatalf("NewFixedWindowLimiter failed, err:%v\n", err)
+		}
+		if l.window != tt.expected {
+			t.Fatalf("NewFixedWindowLimiter failed, expected:%v, got:%v\n", tt.expected, l.window)
+		}
+	}
+
+	if _, err := NewFixedWindowLimiter(nil, "", 1, 500*time.Millisecond); err == nil {
+		t.Fatalf("NewFixedWindowLimiter failed, expected error for window under 1 second\n")
+	}
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "ratelimit_test:sliding"
+	l := NewSlidingWindowLimiter(p, "ratelimit_test:", 5, time.Second)
+	testRateLimiter(t, l, "sliding", 5)
+
+	time.Sleep(time.Second)
+	res, err := l.Allow("sliding")
+	if err != nil || !res.Allowed {
+		t.Fatalf("Allow failed, expected allowed after the window, got:%+v, err:%v\n", res, err)
+	}
+
+	c := p.Get()
+	c.Zclear(name)
+	p.Release(c)
+}
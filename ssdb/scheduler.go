@@ -0,0 +1,318 @@
+package ssdb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ScheduledJob is a job due, passed to the handler of a Scheduler.
+type ScheduledJob struct {
+	ID   string
+	Body string
+	// At is when the job was due.
+	At time.Time
+	// Spec is the schedule spec of a recurring job, empty for a one-off job.
+	Spec string
+}
+
+// Scheduler runs delayed and recurring jobs, stored in the zset name scored by their due times
+// in Unix milliseconds. The bodies and the schedule specs of jobs are kept in the hashmaps
+// name+":jobs" and name+":specs".
+// Many pollers may compete for the jobs, a due job is claimed by a kv key name+":claim:"+id
+// with a short lease, so it's run by one poller at a time. A claimed job is rescheduled to the
+// expiry of the lease, so it leaves the jobs due, and it's retried then if it failed, or its poller crashed.
+type Scheduler struct {
+	r     Runner
+	name  string
+	jobs  string
+	specs string
+	lease time.Duration
+}
+
+// NewScheduler returns a Scheduler on a Client or a Pool, the claims of jobs last lease(at least 1 second),
+// which should be longer than the handlers take.
+func NewScheduler(r Runner, name string, lease time.Duration) *Scheduler {
+	if lease < time.Second {
+		lease = time.Second
+	}
+	return &Scheduler{
+		r:     r,
+		name:  name,
+		jobs:  name + ":jobs",
+		specs: name + ":specs",
+		lease: lease,
+	}
+}
+
+// Schedule adds a one-off job due at, and returns its id.
+func (s *Scheduler) Schedule(at time.Time, body interface{}) (string, error) {
+	id, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.r.Run(func(c *Client) error {
+		_, err := c.Hset(s.jobs, id, body)
+		if err != nil {
+			return err
+		}
+		_, err = c.Zset(s.name, id, at.UnixMilli())
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// After adds a one-off job due after d, and returns its id.
+func (s *Scheduler) After(d time.Duration, body interface{}) (string, error) {
+	return s.Schedule(time.Now().Add(d), body)
+}
+
+// Recur adds a recurring job by a schedule spec, see ParseSchedule for the specs.
+// The job with the same id is replaced, so recurring jobs can be added on every start of services.
+func (s *Scheduler) Recur(id, spec string, body interface{}) error {
+	sched, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	next := sched.Next(time.Now())
+	if next.IsZero() {
+		return errors.New("schedule " + spec + " never fires")
+	}
+
+	return s.r.Run(func(c *Client) error {
+		_, err := c.Hset(s.jobs, id, body)
+		if err != nil {
+			return err
+		}
+		_, err = c.Hset(s.specs, id, spec)
+		if err != nil {
+			return err
+		}
+		_, err = c.Zset(s.name, id, next.UnixMilli())
+		return err
+	})
+}
+
+// Cancel deletes the job, and reports whether it existed.
+// A job being run is still finished, but a recurring one won't recur.
+func (s *Scheduler) Cancel(id string) (bool, error) {
+	var n int64
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Zdel(s.name, id)
+		if err != nil {
+			return err
+		}
+		_, err = c.Hdel(s.jobs, id)
+		if err != nil {
+			return err
+		}
+		_, err = c.Hdel(s.specs, id)
+		return err
+	})
+	return n == 1, err
+}
+
+// Poll runs the jobs due, at most limit of them, by handler, and returns the number of jobs run.
+// The jobs claimed by other pollers are skipped. The errors of handler are joined and returned,
+// the jobs failed are retried after the lease expires.
+func (s *Scheduler) Poll(limit int, handler func(job *ScheduledJob) error) (int, error) {
+	n, errs, err := s.poll(limit, handler)
+	if err != nil {
+		return n, err
+	}
+	return n, errors.Join(errs...)
+}
+
+// Serve polls the jobs due every interval, until ctx is done or the server fails.
+// The errors of handler are passed to onError if it's not nil.
+func (s *Scheduler) Serve(ctx context.Context, interval time.Duration, handler func(job *ScheduledJob) error, onError func(err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, errs, err := s.poll(100, handler)
+			if onError != nil {
+				for _, err := range errs {
+					onError(err)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			// more jobs may be due.
+			if n < 100 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll runs the jobs due, it returns the errors of handler and the error of the server separately.
+// The jobs due are scanned page by page, past the ones claimed by others, until limit jobs are run.
+func (s *Scheduler) poll(limit int, handler func(job *ScheduledJob) error) (count int, errs []error, err error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	key, start := "", "-inf"
+	for count < limit {
+		var due []ZMember
+		err = s.r.Run(func(c *Client) error {
+			var err error
+			due, err = c.ZscanByScore(s.name, key, start, now, limit)
+			return err
+		})
+		if err != nil {
+			if err.Error() == "no data found" {
+				return count, errs, nil
+			}
+			return count, errs, err
+		}
+
+		for _, m := range due {
+			job, token, err := s.claim(m)
+			if err != nil {
+				return count, errs, err
+			}
+			if job == nil {
+				continue
+			}
+
+			count++
+			if err := handler(job); err != nil {
+				errs = append(errs, err)
+			} else if err := s.finish(job, token); err != nil {
+				return count, errs, err
+			}
+			if count == limit {
+				break
+			}
+		}
+		if len(due) < limit {
+			break
+		}
+		last := due[len(due)-1]
+		key, start = last.Key, strconv.FormatInt(last.Score, 10)
+	}
+	return count, errs, nil
+}
+
+func (s *Scheduler) claimKey(id string) string {
+	return s.name + ":claim:" + id
+}
+
+// claim takes the lease of the job, it returns nil if the job is claimed by others or canceled.
+func (s *Scheduler) claim(m ZMember) (job *ScheduledJob, token string, err error) {
+	token, err = randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	key := s.claimKey(m.Key)
+	secs := int64(s.lease / time.Second)
+
+	err = s.r.Run(func(c *Client) error {
+		n, err := c.Setnx(key, token)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// the poller may crash between setnx and expire, leaving the claim forever.
+			left, err := c.Ttl(key)
+			if err == nil && left == -1 {
+				_, err = c.Expire(key, secs)
+			}
+			return err
+		}
+		_, err = c.Expire(key, secs)
+		if err != nil {
+			return err
+		}
+
+		body, err := c.Hget(s.jobs, m.Key)
+		if err != nil {
+			if err.Error() != "not_found" {
+				return err
+			}
+			// canceled meanwhile.
+			if _, err = c.Zdel(s.name, m.Key); err != nil {
+				return err
+			}
+			return c.Del(key)
+		}
+		spec, err := c.Hget(s.specs, m.Key)
+		if err != nil && err.Error() != "not_found" {
+			return err
+		}
+		// out of the jobs due until the lease expires.
+		_, err = c.Zset(s.name, m.Key, time.Now().Add(s.lease).UnixMilli())
+		if err != nil {
+			return err
+		}
+		job = &ScheduledJob{ID: m.Key, Body: body, At: time.UnixMilli(m.Score), Spec: spec}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return job, token, nil
+}
+
+// finish reschedules the recurring job or deletes the one-off job, and releases the claim.
+func (s *Scheduler) finish(job *ScheduledJob, token string) error {
+	return s.r.Run(func(c *Client) error {
+		var next time.Time
+		if job.Spec != "" {
+			sched, err := ParseSchedule(job.Spec)
+			if err != nil {
+				return err
+			}
+			next = sched.Next(time.Now())
+		}
+
+		if next.IsZero() {
+			_, err := c.Zdel(s.name, job.ID)
+			if err != nil {
+				return err
+			}
+			_, err = c.Hdel(s.jobs, job.ID)
+			if err != nil {
+				return err
+			}
+			_, err = c.Hdel(s.specs, job.ID)
+			if err != nil {
+				return err
+			}
+		} else {
+			n, err := c.Hexists(s.specs, job.ID)
+			if err != nil {
+				return err
+			}
+			// canceled meanwhile, the entry may be added back by claim.
+			if n == 1 {
+				_, err = c.Zset(s.name, job.ID, next.UnixMilli())
+			} else {
+				_, err = c.Zdel(s.name, job.ID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		key := s.claimKey(job.ID)
+		v, err := c.Get(key)
+		if err == nil && v == token {
+			err = c.Del(key)
+		} else if err != nil && err.Error() == "not_found" {
+			err = nil
+		}
+		return err
+	})
+}
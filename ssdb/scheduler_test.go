@@ -0,0 +1,105 @@
+package ssdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "scheduler_test"
+	s := NewScheduler(p, name, time.Second)
+	defer func() {
+		c := p.Get()
+		c.Zclear(name)
+		c.Hclear(name + ":jobs")
+		c.Hclear(name + ":specs")
+		p.Release(c)
+	}()
+
+	id, err := s.After(-time.Second, "due")
+	if err != nil {
+		t.Fatalf("After failed, err:%v\n", err)
+	}
+	_, err = s.After(time.Hour, "later")
+	if err != nil {
+		t.Fatalf("After failed, err:%v\n", err)
+	}
+	canceled, err := s.After(-time.Second, "canceled")
+	if err != nil {
+		t.Fatalf("After failed, err:%v\n", err)
+	}
+	ok, err := s.Cancel(canceled)
+	if err != nil || !ok {
+		t.Fatalf("Cancel failed, got:%v, err:%v\n", ok, err)
+	}
+
+	// fails first, retried after the lease.
+	fail := errors.New("fail")
+	n, err := s.Poll(10, func(job *ScheduledJob) error {
+		if job.ID != id || job.Body != "due" {
+			t.Fatalf("Poll failed, got:%+v\n", job)
+		}
+		return fail
+	})
+	if n != 1 || !errors.Is(err, fail) {
+		t.Fatalf("Poll failed, got:%v, err:%v\n", n, err)
+	}
+	n, err = s.Poll(10, func(job *ScheduledJob) error { return nil })
+	if n != 0 || err != nil {
+		t.Fatalf("Poll failed, expected claimed, got:%v, err:%v\n", n, err)
+	}
+	time.Sleep(2100 * time.Millisecond)
+	n, err = s.Poll(10, func(job *ScheduledJob) error { return nil })
+	if n != 1 || err != nil {
+		t.Fatalf("Poll failed, got:%v, err:%v\n", n, err)
+	}
+	n, err = s.Poll(10, func(job *ScheduledJob) error { return nil })
+	if n != 0 || err != nil {
+		t.Fatalf("Poll failed, expected done, got:%v, err:%v\n", n, err)
+	}
+
+	// the jobs claimed by others at the head don't starve the later ones.
+	var ids []string
+	for i := 3; i > 0; i-- {
+		id, err := s.Schedule(time.Now().Add(-time.Duration(i)*time.Second), "starve")
+		if err != nil {
+			t.Fatalf("Schedule failed, err:%v\n", err)
+		}
+		ids = append(ids, id)
+	}
+	c := p.Get()
+	for _, id := range ids[:2] {
+		err = c.Setx(name+":claim:"+id, "other", 2)
+	}
+	p.Release(c)
+	if err != nil {
+		t.Fatalf("Setx failed, err:%v\n", err)
+	}
+	n, err = s.Poll(2, func(job *ScheduledJob) error {
+		if job.ID != ids[2] {
+			t.Fatalf("Poll failed, expected:%v, got:%v\n", ids[2], job.ID)
+		}
+		return nil
+	})
+	if n != 1 || err != nil {
+		t.Fatalf("Poll failed, expected 1 run past the claimed, got:%v, err:%v\n", n, err)
+	}
+	for _, id := range ids[:2] {
+		s.Cancel(id)
+	}
+
+	err = s.Recur("daily", "@daily", "report")
+	if err != nil {
+		t.Fatalf("Recur failed, err:%v\n", err)
+	}
+	ok, err = s.Cancel("daily")
+	if err != nil || !ok {
+		t.Fatalf("Cancel failed, got:%v, err:%v\n", ok, err)
+	}
+}
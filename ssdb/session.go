@@ -0,0 +1,349 @@
+package ssdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SessionOptions configures a SessionStore.
+type SessionOptions struct {
+	// CookieName is the name of the cookie of session ids, "session" by default.
+	CookieName string
+	// Prefix is the prefix of the keys of sessions, "session:" by default.
+	Prefix string
+	// TTL is how long a session lasts since its last access, 30 minutes by default.
+	TTL time.Duration
+	// Codec encodes the session data, JSONCodec by default.
+	Codec Codec
+	// ErrorHandler is called by Middleware when it fails to save the session of the request,
+	// the response is already on the way then. The error is logged by default.
+	ErrorHandler func(r *http.Request, err error)
+
+	// The attributes of the cookie.
+	Path     string
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// SessionStore stores HTTP sessions as kv keys by setx, the key of a session is Prefix+id.
+// The ids are random 256-bit strings, and the ids in cookies not found in the server are
+// replaced with new ones, rather than adopted.
+type SessionStore struct {
+	r    Runner
+	opts SessionOptions
+}
+
+// Session is the data of a user across HTTP requests. It's not goroutine-safe.
+type Session struct {
+	store *SessionStore
+	id    string
+	data  sessionData
+
+	isNew     bool
+	dirty     bool
+	destroyed bool
+	// oldID is the id replaced by Rotate, deleted on saving.
+	oldID string
+}
+
+type sessionData struct {
+	Values  map[string]interface{}
+	Flashes []interface{}
+}
+
+type sessionContextKey struct{}
+
+// NewSessionStore returns a SessionStore on a Pool or a Client, opts may be nil for the defaults.
+func NewSessionStore(r Runner, opts *SessionOptions) *SessionStore {
+	s := &SessionStore{r: r}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.CookieName == "" {
+		s.opts.CookieName = "session"
+	}
+	if s.opts.Prefix == "" {
+		s.opts.Prefix = "session:"
+	}
+	if s.opts.TTL < time.Second {
+		s.opts.TTL = 30 * time.Minute
+	}
+	if s.opts.Codec == nil {
+		s.opts.Codec = JSONCodec
+	}
+	if s.opts.Path == "" {
+		s.opts.Path = "/"
+	}
+	if s.opts.ErrorHandler == nil {
+		s.opts.ErrorHandler = func(r *http.Request, err error) {
+			log.Printf("ssdb: saving session of %s failed, err:%v", r.URL.Path, err)
+		}
+	}
+	return s
+}
+
+// New returns a new empty session.
+func (s *SessionStore) New() (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		store: s,
+		id:    id,
+		data:  sessionData{Values: map[string]interface{}{}},
+		isNew: true,
+	}, nil
+}
+
+// Load returns the session of the request, or a new session if there is none.
+// The TTL of the session is refreshed.
+func (s *SessionStore) Load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(s.opts.CookieName)
+	if err != nil || !validSessionID(cookie.Value) {
+		return s.New()
+	}
+
+	id := cookie.Value
+	var value string
+	err = s.r.Run(func(c *Client) error {
+		var err error
+		value, err = c.Get(s.opts.Prefix + id)
+		if err != nil {
+			return err
+		}
+		_, err = c.Expire(s.opts.Prefix+id, s.ttl())
+		return err
+	})
+	if err != nil {
+		if err.Error() == "not_found" {
+			return s.New()
+		}
+		return nil, err
+	}
+
+	sess := &Session{store: s, id: id}
+	err = s.opts.Codec.Unmarshal([]byte(value), &sess.data)
+	if err != nil {
+		return nil, err
+	}
+	if sess.data.Values == nil {
+		sess.data.Values = map[string]interface{}{}
+	}
+	return sess, nil
+}
+
+// Save stores the session if it's changed, and sets the cookie of the session id.
+// A new session is not stored until it's set. It must be called before writing the response body.
+func (s *SessionStore) Save(w http.ResponseWriter, sess *Session) error {
+	if sess.destroyed {
+		err := s.r.Run(func(c *Client) error {
+			if sess.oldID != "" {
+				if err := c.Del(s.opts.Prefix + sess.oldID); err != nil {
+					return err
+				}
+			}
+			return c.Del(s.opts.Prefix + sess.id)
+		})
+		if err != nil {
+			return err
+		}
+		http.SetCookie(w, s.cookie("", -1))
+		return nil
+	}
+
+	if sess.isNew && !sess.dirty {
+		// nothing to keep.
+		return nil
+	}
+	if sess.dirty {
+		data, err := s.opts.Codec.Marshal(&sess.data)
+		if err != nil {
+			return err
+		}
+		err = s.r.Run(func(c *Client) error {
+			if err := c.Setx(s.opts.Prefix+sess.id, data, s.ttl()); err != nil {
+				return err
+			}
+			if sess.oldID != "" {
+				return c.Del(s.opts.Prefix + sess.oldID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sess.isNew, sess.dirty, sess.oldID = false, false, ""
+	}
+	http.SetCookie(w, s.cookie(sess.id, int(s.ttl())))
+	return nil
+}
+
+func (s *SessionStore) ttl() int64 {
+	return int64(s.opts.TTL / time.Second)
+}
+
+func (s *SessionStore) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    value,
+		Path:     s.opts.Path,
+		Domain:   s.opts.Domain,
+		MaxAge:   maxAge,
+		Secure:   s.opts.Secure,
+		HttpOnly: true,
+		SameSite: s.opts.SameSite,
+	}
+}
+
+// Middleware loads the session of every request into its context for SessionFromContext,
+// and saves it right before the response is written, or after next returns if nothing is written.
+// A failure of loading the session is responded with 500 Internal Server Error, and a failure of
+// saving it is passed to SessionOptions.ErrorHandler, then saving is retried after next returns.
+func (s *SessionStore) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess, err := s.Load(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		sw := &sessionWriter{ResponseWriter: w, store: s, sess: sess, r: r}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, sess)))
+		sw.save()
+	})
+}
+
+// SessionFromContext returns the session loaded by SessionStore.Middleware, or nil if there is none.
+func SessionFromContext(ctx context.Context) *Session {
+	sess, _ := ctx.Value(sessionContextKey{}).(*Session)
+	return sess
+}
+
+// sessionWriter saves the session before the header is written.
+type sessionWriter struct {
+	http.ResponseWriter
+	store *SessionStore
+	sess  *Session
+	r     *http.Request
+	// tried is set once saving is tried before the header is written, saved once it succeeds.
+	tried bool
+	saved bool
+}
+
+func (w *sessionWriter) save() {
+	if w.saved {
+		return
+	}
+	w.tried = true
+	// the response is on the way, the session is left unchanged on failure.
+	if err := w.store.Save(w.ResponseWriter, w.sess); err != nil {
+		w.store.opts.ErrorHandler(w.r, err)
+		return
+	}
+	w.saved = true
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	if !w.tried {
+		w.save()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	if !w.tried {
+		w.save()
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the ResponseWriter wrapped, for http.ResponseController.
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ID returns the id of the session.
+func (sess *Session) ID() string {
+	return sess.id
+}
+
+// IsNew reports whether the session is not stored yet.
+func (sess *Session) IsNew() bool {
+	return sess.isNew
+}
+
+// Get returns the value of key.
+func (sess *Session) Get(key string) interface{} {
+	return sess.data.Values[key]
+}
+
+// Set sets the value of key.
+func (sess *Session) Set(key string, value interface{}) {
+	sess.data.Values[key] = value
+	sess.dirty = true
+}
+
+// Delete deletes the value of key.
+func (sess *Session) Delete(key string) {
+	if _, ok := sess.data.Values[key]; ok {
+		delete(sess.data.Values, key)
+		sess.dirty = true
+	}
+}
+
+// AddFlash adds a flash message, which is kept until it's read by Flashes.
+func (sess *Session) AddFlash(value interface{}) {
+	sess.data.Flashes = append(sess.data.Flashes, value)
+	sess.dirty = true
+}
+
+// Flashes returns the flash messages and clears them.
+func (sess *Session) Flashes() []interface{} {
+	flashes := sess.data.Flashes
+	if len(flashes) > 0 {
+		sess.data.Flashes = nil
+		sess.dirty = true
+	}
+	return flashes
+}
+
+// Rotate replaces the id of the session with a new one, keeping the data,
+// the old id is invalidated on saving. Rotate the id on login against session fixation.
+func (sess *Session) Rotate() error {
+	id, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	if sess.oldID == "" && !sess.isNew {
+		sess.oldID = sess.id
+	}
+	sess.id = id
+	sess.dirty = true
+	return nil
+}
+
+// Destroy deletes the session and expires its cookie on saving.
+func (sess *Session) Destroy() {
+	sess.destroyed = true
+}
+
+const sessionIDLen = 32
+
+func newSessionID() (string, error) {
+	b := make([]byte, sessionIDLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validSessionID(id string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil && len(b) == sessionIDLen
+}
@@ -0,0 +1,112 @@
+package ssdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionID(t *testing.T) {
+	id, err := newSessionID()
+	if err != nil {
+		t.Fatalf("newSessionID failed, err:%v\n", err)
+	}
+	if !validSessionID(id) {
+		t.Fatalf("validSessionID failed, %q is valid\n", id)
+	}
+	for _, id := range []string{"", "abc", id[1:], id[:len(id)-1] + "!"} {
+		if validSessionID(id) {
+			t.Fatalf("validSessionID failed, %q is invalid\n", id)
+		}
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewSessionStore(p, &SessionOptions{Prefix: "session_test:"})
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess := SessionFromContext(r.Context())
+		switch r.URL.Path {
+		case "/login":
+			sess.Set("user", "alice")
+			sess.AddFlash("welcome")
+			sess.Rotate()
+			// the flash is read by the next request.
+			fmt.Fprintf(w, "%v", sess.Get("user"))
+			return
+		case "/logout":
+			sess.Destroy()
+		}
+		fmt.Fprintf(w, "%v %v", sess.Get("user"), sess.Flashes())
+	}))
+
+	do := func(path string, cookie *http.Cookie) (string, *http.Cookie) {
+		r := httptest.NewRequest("GET", path, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		cookies := w.Result().Cookies()
+		if len(cookies) == 0 {
+			return w.Body.String(), nil
+		}
+		return w.Body.String(), cookies[0]
+	}
+
+	body, anon := do("/", nil)
+	if body != "<nil> []" || anon != nil {
+		t.Fatalf("Load failed, got:%q, cookie:%v\n", body, anon)
+	}
+	body, login := do("/login", nil)
+	if body != "alice" || login == nil {
+		t.Fatalf("Save failed, got:%q, cookie:%v\n", body, login)
+	}
+	body, cookie := do("/", login)
+	if body != "alice [welcome]" || cookie.Value != login.Value {
+		t.Fatalf("Load failed, got:%q\n", body)
+	}
+	body, _ = do("/", login)
+	if body != "alice []" {
+		t.Fatalf("Flashes failed, got:%q\n", body)
+	}
+	_, cookie = do("/logout", login)
+	if cookie.MaxAge >= 0 {
+		t.Fatalf("Destroy failed, the cookie is not expired\n")
+	}
+	body, _ = do("/", login)
+	if body != "<nil> []" {
+		t.Fatalf("Destroy failed, got:%q\n", body)
+	}
+}
+
+func TestSessionSaveError(t *testing.T) {
+	s, c := newRecordingServer(t, "setx")
+	defer s.ln.Close()
+	defer c.Close()
+
+	var errs []error
+	store := NewSessionStore(c, &SessionOptions{
+		ErrorHandler: func(r *http.Request, err error) { errs = append(errs, err) },
+	})
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SessionFromContext(r.Context()).Set("user", "alice")
+		fmt.Fprint(w, "a")
+		fmt.Fprint(w, "b")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	// tried before the body is written and after the handler returns.
+	if len(errs) != 2 || errs[0].Error() != "error" {
+		t.Fatalf("Middleware failed, expected 2 errors, got:%v\n", errs)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Fatalf("Middleware failed, cookie set for the session not saved\n")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Debug indicates whether to print the server response.
@@ -16,6 +17,24 @@ type Client struct {
 	sock    *net.TCPConn
 	recvBuf bytes.Buffer
 	err     error
+	// What the server supports, detected by Capabilities.
+	caps *Capabilities
+	// Codec for the *Object methods.
+	codec Codec
+	// Values not shorter than it are compressed, 0 to disable.
+	compressThreshold int
+	// The prefix of keys and names, empty for no namespace.
+	namespace string
+	// Encrypts values, nil to disable.
+	encryptor *encryptor
+	// Serializes the functions passed to Run.
+	runMu sync.Mutex
+}
+
+// Runner runs functions with a Client connection, it's implemented by Client and Pool,
+// so the helpers like Locker work with both of them.
+type Runner interface {
+	Run(f func(c *Client) error) error
 }
 
 // Connect returns a Client.
@@ -38,6 +57,15 @@ func (c *Client) Close() error {
 	return c.sock.Close()
 }
 
+// Run runs f with the Client, the functions passed to Run are serialized,
+// so the helpers sharing the Client may run them from multiple goroutines.
+// The methods of Client called directly are still not goroutine-safe.
+func (c *Client) Run(f func(c *Client) error) error {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	return f(c)
+}
+
 // Auth verifies the password for the server.
 func (c *Client) Auth(pwd string) error {
 	return c.doReturn("auth", pwd)
@@ -51,8 +79,16 @@ func (c *Client) DBsize() (int64, error) {
 // FlushDB deletes all data in ssdb server. If type is provided, delete all data of specific type.
 // The optional dataType, could be kv, hash, zset, list, and empty to delete all.
 // Notice: The command "flushdb" is not a real command until 1.9.2, before that,
-// it is provided by ssdb-cli, not on the server side.
+// it is provided by ssdb-cli, not on the server side, so it is emulated by listing and deleting.
+// It's emulated too if the Client has a namespace, to delete the data in the namespace only.
 func (c *Client) FlushDB(dataType string) error {
+	ok, err := c.supports("flushdb")
+	if err != nil {
+		return err
+	}
+	if !ok || c.namespace != "" {
+		return c.emulateFlushDB(dataType)
+	}
 	return c.doReturn("flushdb", dataType)
 }
 
@@ -62,32 +98,181 @@ func (c *Client) Info(dataType string) (string, error) {
 	return c.doReturnString("info", dataType)
 }
 
+// Ping checks whether the server is alive.
+func (c *Client) Ping() error {
+	return c.doReturn("ping")
+}
+
+// Version returns the version of the server, like 1.9.4.
+func (c *Client) Version() (string, error) {
+	return c.doReturnString("version")
+}
+
+// Compact compacts the leveldb data files of the server.
+// Caution: Important! It may take a long time and make the server busy, use it off-peak.
+func (c *Client) Compact() error {
+	return c.doReturn("compact")
+}
+
+// KeyRange holds the first and the last key of each data type stored in the server.
+type KeyRange struct {
+	KvStart   string
+	KvEnd     string
+	HashStart string
+	HashEnd   string
+	ZsetStart string
+	ZsetEnd   string
+	ListStart string
+	ListEnd   string
+}
+
+// KeyRange returns the key range of each data type stored in the server.
+func (c *Client) KeyRange() (*KeyRange, error) {
+	resp, err := c.doReturnStringSlice("key_range")
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 8 {
+		return nil, fmt.Errorf("bad response, expected 8 keys, got %v", len(resp))
+	}
+	return &KeyRange{
+		KvStart: resp[0], KvEnd: resp[1],
+		HashStart: resp[2], HashEnd: resp[3],
+		ZsetStart: resp[4], ZsetEnd: resp[5],
+		ListStart: resp[6], ListEnd: resp[7],
+	}, nil
+}
+
+// GetKeyRange returns the range (start, end] of kv keys the server is serving,
+// empty string means no limit.
+func (c *Client) GetKeyRange() (start, end string, err error) {
+	resp, err := c.doReturnStringSlice("get_key_range")
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp) < 2 {
+		return "", "", fmt.Errorf("bad response, expected 2 keys, got %v", len(resp))
+	}
+	return resp[0], resp[1], nil
+}
+
+// SetKeyRange sets the range (start, end] of kv keys the server is serving, used by data migration.
+func (c *Client) SetKeyRange(start, end string) error {
+	return c.doReturn("set_key_range", start, end)
+}
+
+// IgnoreKeyRange makes the server ignore the key range set by SetKeyRange on this connection.
+func (c *Client) IgnoreKeyRange() error {
+	return c.doReturn("ignore_key_range")
+}
+
+// ClearBinlog deletes all the binlogs of the server.
+func (c *Client) ClearBinlog() error {
+	return c.doReturn("clear_binlog")
+}
+
+/*
+Slaveof makes the server a slave of another server, starting sync from it.
+Parameters
+    id - The id of the slave, it should be unique in all the slaves of the master.
+    host - The ip of the master.
+    port - The port of the master.
+    args - Optional, auth last_seq last_key, the password of the master, and the position to start sync from.
+*/
+func (c *Client) Slaveof(id, host string, port int, args ...interface{}) error {
+	return c.doReturn("slaveof", id, host, port, args)
+}
+
+// ListAllowIP returns the ip rules allowed to connect to the server.
+func (c *Client) ListAllowIP() ([]string, error) {
+	if err := c.require("list_allow_ip"); err != nil {
+		return nil, err
+	}
+	return c.doReturnStringSlice("list_allow_ip")
+}
+
+// AddAllowIP adds an ip rule which is allowed to connect to the server, like 127.0.0.1 or 192.168.
+func (c *Client) AddAllowIP(rule string) error {
+	if err := c.require("add_allow_ip"); err != nil {
+		return err
+	}
+	return c.doReturn("add_allow_ip", rule)
+}
+
+// DelAllowIP deletes an ip rule added by AddAllowIP.
+func (c *Client) DelAllowIP(rule string) error {
+	if err := c.require("del_allow_ip"); err != nil {
+		return err
+	}
+	return c.doReturn("del_allow_ip", rule)
+}
+
+// ListDenyIP returns the ip rules denied to connect to the server.
+func (c *Client) ListDenyIP() ([]string, error) {
+	if err := c.require("list_deny_ip"); err != nil {
+		return nil, err
+	}
+	return c.doReturnStringSlice("list_deny_ip")
+}
+
+// AddDenyIP adds an ip rule which is denied to connect to the server, like 127.0.0.1 or 192.168.
+func (c *Client) AddDenyIP(rule string) error {
+	if err := c.require("add_deny_ip"); err != nil {
+		return err
+	}
+	return c.doReturn("add_deny_ip", rule)
+}
+
+// DelDenyIP deletes an ip rule added by AddDenyIP.
+func (c *Client) DelDenyIP(rule string) error {
+	if err := c.require("del_deny_ip"); err != nil {
+		return err
+	}
+	return c.doReturn("del_deny_ip", rule)
+}
+
 // Set sets the value of the key.
 func (c *Client) Set(key string, value interface{}) error {
+	value, err := c.packValue(value)
+	if err != nil {
+		return err
+	}
 	return c.doReturn("set", key, value)
 }
 
 // Setx sets the value of the key, with a number of seconds to live.
 func (c *Client) Setx(key string, value interface{}, ttl int64) error {
+	value, err := c.packValue(value)
+	if err != nil {
+		return err
+	}
 	return c.doReturn("setx", key, value, ttl)
 }
 
 // Setnx sets the value only when the key doesn't exist.
 // Return values: 1: value is set, 0: key already exists.
 func (c *Client) Setnx(key string, value interface{}) (int64, error) {
+	value, err := c.packValue(value)
+	if err != nil {
+		return 0, err
+	}
 	return c.doReturnInt("setnx", key, value)
 }
 
 // Get returns the value of the key. If the key is not existed, error "not_found" is returned.
 func (c *Client) Get(key string) (string, error) {
-	return c.doReturnString("get", key)
+	return c.unpackString(c.doReturnString("get", key))
 }
 
 // Getset Sets a value and returns the previous entry at that key.
 // If the key already exists, the value related to that key is returned.
 // Otherwise return not_found Status Code. The value is either added or updated.
 func (c *Client) Getset(key string, value interface{}) (string, error) {
-	return c.doReturnString("getset", key, value)
+	value, err := c.packValue(value)
+	if err != nil {
+		return "", err
+	}
+	return c.unpackString(c.doReturnString("getset", key, value))
 }
 
 // Del deletes the specified key.
@@ -158,6 +343,7 @@ func (c *Client) Countbit(key string, args ...int) (int64, error) {
 
 /*
 Bitcount counts the number of set bits (population counting) in a string. Like Redis's bitcount.
+For servers before 1.7.0, it is emulated by strlen and countbit.
 Parameters
     key -
     start - Optional, inclusive, if start is negative, count from start'th character from the end of string.
@@ -166,6 +352,13 @@ Return Value
 	The number of bits set to 1.
 */
 func (c *Client) Bitcount(key string, args ...int) (int64, error) {
+	ok, err := c.supports("bitcount")
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return c.emulateBitcount(key, args)
+	}
 	return c.doReturnInt("bitcount", key, args)
 }
 
@@ -220,12 +413,12 @@ Return Value
 	An associative array containing the key-value pairs. Like [k1 v1 k2 v2 ...]
 */
 func (c *Client) Scan(keyStart, keyEnd string, limit int) (OrderedMap, error) {
-	return c.doReturnStringMap("scan", keyStart, keyEnd, limit)
+	return c.unpackMap(c.doReturnStringMap("scan", keyStart, keyEnd, limit))
 }
 
 // Rscan works likely Scan, but in reverse order.
 func (c *Client) Rscan(keyStart, keyEnd string, limit int) (OrderedMap, error) {
-	return c.doReturnStringMap("rscan", keyStart, keyEnd, limit)
+	return c.unpackMap(c.doReturnStringMap("rscan", keyStart, keyEnd, limit))
 }
 
 /*
@@ -236,6 +429,10 @@ Return Value
 	Number of keys are set.
 */
 func (c *Client) MultiSet(args ...interface{}) (int64, error) {
+	args, err := c.packPairs(args)
+	if err != nil {
+		return 0, err
+	}
 	return c.doReturnInt("multi_set", args)
 }
 
@@ -247,7 +444,7 @@ Return Value
 	Key-value list.
 */
 func (c *Client) MultiGet(keys ...interface{}) ([]string, error) {
-	return c.doReturnStringSlice("multi_get", keys)
+	return c.unpackPairs(c.doReturnStringSlice("multi_get", keys))
 }
 
 /*
@@ -261,6 +458,17 @@ func (c *Client) MultiDel(keys ...interface{}) (int64, error) {
 	return c.doReturnInt("multi_del", keys)
 }
 
+/*
+MultiExists checks whether the specified keys exist.
+Parameters
+    key1 key2 ...
+Return Value
+	Key-existence map, true if the key exists.
+*/
+func (c *Client) MultiExists(keys ...interface{}) (map[string]bool, error) {
+	return c.doReturnBoolMap("multi_exists", keys)
+}
+
 // For hash map operations.
 /*
 Hset sets the string value in argument as value of the key of a hashmap.
@@ -272,6 +480,10 @@ Return Value
 	Returns 1 if key is a new key in the hashmap and value is set, else returns 0.
 */
 func (c *Client) Hset(name, key string, value interface{}) (int64, error) {
+	value, err := c.packValue(value)
+	if err != nil {
+		return 0, err
+	}
 	return c.doReturnInt("hset", name, key, value)
 }
 
@@ -284,7 +496,7 @@ Return Value
 	Return the value to the key, if the key does not exists, return not_found Status Code.
 */
 func (c *Client) Hget(name, key string) (string, error) {
-	return c.doReturnString("hget", name, key)
+	return c.unpackString(c.doReturnString("hget", name, key))
 }
 
 // Hdel deletes specified key of a hashmap.
@@ -335,7 +547,7 @@ func (c *Client) Hkeys(name, keyStart, keyEnd string, limit int) ([]string, erro
 
 // Hgetall returns the whole hash, as an array of strings indexed by strings.
 func (c *Client) Hgetall(name string) (OrderedMap, error) {
-	return c.doReturnStringMap("hgetall", name)
+	return c.unpackMap(c.doReturnStringMap("hgetall", name))
 }
 
 /*
@@ -343,12 +555,12 @@ Hscan lists key-value pairs of a hashmap with keys in range (key_start, key_end]
 For more details, refer Scan.
 */
 func (c *Client) Hscan(name, keyStart, keyEnd string, limit int) (OrderedMap, error) {
-	return c.doReturnStringMap("hscan", name, keyStart, keyEnd, limit)
+	return c.unpackMap(c.doReturnStringMap("hscan", name, keyStart, keyEnd, limit))
 }
 
 // Hrscan works likely Hscan, but in reverse order.
 func (c *Client) Hrscan(name, keyStart, keyEnd string, limit int) (OrderedMap, error) {
-	return c.doReturnStringMap("hrscan", name, keyStart, keyEnd, limit)
+	return c.unpackMap(c.doReturnStringMap("hrscan", name, keyStart, keyEnd, limit))
 }
 
 /*
@@ -367,6 +579,10 @@ Return Value
 	Number of keys are set.
 */
 func (c *Client) MultiHset(name string, args ...interface{}) (int64, error) {
+	args, err := c.packPairs(args)
+	if err != nil {
+		return 0, err
+	}
 	return c.doReturnInt("multi_hset", name, args)
 }
 
@@ -378,7 +594,7 @@ Return Value
 	Key-value list.
 */
 func (c *Client) MultiHget(name string, keys ...interface{}) ([]string, error) {
-	return c.doReturnStringSlice("multi_hget", name, keys)
+	return c.unpackPairs(c.doReturnStringSlice("multi_hget", name, keys))
 }
 
 /*
@@ -392,6 +608,28 @@ func (c *Client) MultiHdel(name string, keys ...interface{}) (int64, error) {
 	return c.doReturnInt("multi_hdel", name, keys)
 }
 
+/*
+MultiHexists checks whether the specified keys exist in a hashmap.
+Parameters
+    name key1 key2 ...
+Return Value
+	Key-existence map, true if the key exists.
+*/
+func (c *Client) MultiHexists(name string, keys ...interface{}) (map[string]bool, error) {
+	return c.doReturnBoolMap("multi_hexists", name, keys)
+}
+
+/*
+MultiHsize returns the number of key-value pairs of the specified hashmaps.
+Parameters
+    name1 name2 ...
+Return Value
+	Name-size map, the size is 0 if the hashmap does not exist.
+*/
+func (c *Client) MultiHsize(names ...interface{}) (map[string]int64, error) {
+	return c.doReturnIntMap("multi_hsize", names)
+}
+
 // For hash map operations.
 /*
 Zset sets the score of the key of a zset.
@@ -578,6 +816,7 @@ func (c *Client) Zremrangebyscore(name string, start, end int64) (int64, error)
 
 /*
 Zpopfront deletes and returns `limit` element(s) from front of the zset.
+For servers before 1.9.0, it is emulated by zrange and multi_zdel, which is not atomic.
 Parameters
     name - The name of the zset.
     limit - The number of elements to be deleted and returned.
@@ -585,11 +824,19 @@ Return Value
 	false on error, otherwise an array containing key-score pairs.
 */
 func (c *Client) Zpopfront(name string, limit int) (OrderedMap, error) {
+	ok, err := c.supports("zpop_front")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.emulateZpop("zrange", name, limit)
+	}
 	return c.doReturnStringMap("zpop_front", name, limit)
 }
 
 /*
 Zpopback deletes and returns `limit` element(s) from back of the zset.
+For servers before 1.9.0, it is emulated by zrrange and multi_zdel, which is not atomic.
 Parameters
     name - The name of the zset.
     limit - The number of elements to be deleted and returned.
@@ -597,6 +844,13 @@ Return Value
 	false on error, otherwise an array containing key-score pairs.
 */
 func (c *Client) Zpopback(name string, limit int) (OrderedMap, error) {
+	ok, err := c.supports("zpop_back")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.emulateZpop("zrrange", name, limit)
+	}
 	return c.doReturnStringMap("zpop_back", name, limit)
 }
 
@@ -633,6 +887,28 @@ func (c *Client) MultiZdel(name string, keys ...interface{}) (int64, error) {
 	return c.doReturnInt("multi_zdel", name, keys)
 }
 
+/*
+MultiZexists checks whether the specified keys exist in a zset.
+Parameters
+    name key1 key2 ...
+Return Value
+	Key-existence map, true if the key exists.
+*/
+func (c *Client) MultiZexists(name string, keys ...interface{}) (map[string]bool, error) {
+	return c.doReturnBoolMap("multi_zexists", name, keys)
+}
+
+/*
+MultiZsize returns the number of key-score pairs of the specified zsets.
+Parameters
+    name1 name2 ...
+Return Value
+	Name-size map, the size is 0 if the zset does not exist.
+*/
+func (c *Client) MultiZsize(names ...interface{}) (map[string]int64, error) {
+	return c.doReturnIntMap("multi_zsize", names)
+}
+
 /*
 QpushFront adds one or more than one element to the head of the queue.
 Parameters
@@ -641,6 +917,10 @@ Return Value
 	The length of the list after the push operation, false on error.
 */
 func (c *Client) QpushFront(name string, values ...interface{}) (int64, error) {
+	values, err := c.packValues(values)
+	if err != nil {
+		return 0, err
+	}
 	return c.doReturnInt("qpush_front", name, values)
 }
 
@@ -652,6 +932,10 @@ Return Value
 	The length of the list after the push operation, false on error.
 */
 func (c *Client) QpushBack(name string, values ...interface{}) (int64, error) {
+	values, err := c.packValues(values)
+	if err != nil {
+		return 0, err
+	}
 	return c.doReturnInt("qpush_back", name, values)
 }
 
@@ -665,7 +949,7 @@ Return Value
 	When size is specified and greater than or equal to 2, returns an array of elements removed.
 */
 func (c *Client) QpopFront(name string, size int) ([]string, error) {
-	return c.doReturnStringSlice("qpop_front", name, size)
+	return c.unpackValues(c.doReturnStringSlice("qpop_front", name, size))
 }
 
 /*
@@ -678,7 +962,7 @@ Return Value
 	When size is specified and greater than or equal to 2, returns an array of elements removed.
 */
 func (c *Client) QpopBack(name string, size int) ([]string, error) {
-	return c.doReturnStringSlice("qpop_back", name, size)
+	return c.unpackValues(c.doReturnStringSlice("qpop_back", name, size))
 }
 
 // Qpush is alias of QpushBack.
@@ -694,13 +978,13 @@ func (c *Client) Qpop(name string, size int) ([]string, error) {
 // Qfront returns the first element of a queue.
 // It returns null if queue empty, otherwise the item returned.
 func (c *Client) Qfront(name string) (string, error) {
-	return c.doReturnString("qfront", name)
+	return c.unpackString(c.doReturnString("qfront", name))
 }
 
 // Qback returns the last element of a queue.
 // It returns null if queue empty, otherwise the item returned.
 func (c *Client) Qback(name string) (string, error) {
-	return c.doReturnString("qback", name)
+	return c.unpackString(c.doReturnString("qback", name))
 }
 
 /*
@@ -726,7 +1010,7 @@ Return Value
 	false on error, null if no element corresponds to this index, otherwise the item returned.
 */
 func (c *Client) Qget(name string, index int) (string, error) {
-	return c.doReturnString("qget", name, index)
+	return c.unpackString(c.doReturnString("qget", name, index))
 }
 
 /*
@@ -739,6 +1023,10 @@ Return Value
 	false on error, other values indicate OK.
 */
 func (c *Client) Qset(name string, index int, value interface{}) error {
+	value, err := c.packValue(value)
+	if err != nil {
+		return err
+	}
 	return c.doReturn("qset", name, index, value)
 }
 
@@ -748,7 +1036,7 @@ Return Value
 	false on error, otherwise an array containing items.
 */
 func (c *Client) Qrange(name string, offset, limit int) ([]string, error) {
-	return c.doReturnStringSlice("qrange", name, offset, limit)
+	return c.unpackValues(c.doReturnStringSlice("qrange", name, offset, limit))
 }
 
 /*
@@ -757,11 +1045,12 @@ Return Value
 	false on error, otherwise an array containing items.
 */
 func (c *Client) Qslice(name string, begin, end int) ([]string, error) {
-	return c.doReturnStringSlice("qslice", name, begin, end)
+	return c.unpackValues(c.doReturnStringSlice("qslice", name, begin, end))
 }
 
 /*
 QtrimFront removes multiple elements from the head of a queue.
+For servers before 1.7.0, it is emulated by qpop_front.
 Parameters
     name -
     size - Number of elements to delete.
@@ -769,11 +1058,19 @@ Return Value
 	false on error. Return the number of elements removed.
 */
 func (c *Client) QtrimFront(name string, size int) (int64, error) {
+	ok, err := c.supports("qtrim_front")
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return c.emulateQtrim("qpop_front", name, size)
+	}
 	return c.doReturnInt("qtrim_front", name, size)
 }
 
 /*
 QtrimBack removes multiple elements from the tail of a queue.
+For servers before 1.7.0, it is emulated by qpop_back.
 Parameters
     name -
     size - Number of elements to delete.
@@ -781,6 +1078,13 @@ Return Value
 	false on error. Return the number of elements removed.
 */
 func (c *Client) QtrimBack(name string, size int) (int64, error) {
+	ok, err := c.supports("qtrim_back")
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return c.emulateQtrim("qpop_back", name, size)
+	}
 	return c.doReturnInt("qtrim_back", name, size)
 }
 
@@ -795,12 +1099,7 @@ func (c *Client) Qrlist(nameStart, nameEnd string, limit int) ([]string, error)
 }
 
 func (c *Client) doReturn(args ...interface{}) error {
-	err := c.send(args)
-	if err != nil {
-		return err
-	}
-
-	resp, err := c.recv()
+	resp, err := c.do(args...)
 	if err != nil {
 		return err
 	}
@@ -821,12 +1120,7 @@ func (c *Client) doReturn(args ...interface{}) error {
 }
 
 func (c *Client) doReturnInt(args ...interface{}) (int64, error) {
-	err := c.send(args)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := c.recv()
+	resp, err := c.do(args...)
 	if err != nil {
 		return 0, err
 	}
@@ -853,12 +1147,7 @@ func (c *Client) doReturnInt(args ...interface{}) (int64, error) {
 }
 
 func (c *Client) doReturnString(args ...interface{}) (string, error) {
-	err := c.send(args)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err := c.recv()
+	resp, err := c.do(args...)
 	if err != nil {
 		return "", err
 	}
@@ -885,12 +1174,7 @@ func (c *Client) doReturnString(args ...interface{}) (string, error) {
 }
 
 func (c *Client) doReturnStringSlice(args ...interface{}) ([]string, error) {
-	err := c.send(args)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.recv()
+	resp, err := c.do(args...)
 	if err != nil {
 		return nil, err
 	}
@@ -918,12 +1202,7 @@ func (c *Client) doReturnStringSlice(args ...interface{}) ([]string, error) {
 }
 
 func (c *Client) doReturnStringMap(args ...interface{}) (OrderedMap, error) {
-	err := c.send(args)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.recv()
+	resp, err := c.do(args...)
 	if err != nil {
 		return nil, err
 	}
@@ -949,15 +1228,108 @@ func (c *Client) doReturnStringMap(args ...interface{}) (OrderedMap, error) {
 	}
 }
 
+func (c *Client) doReturnIntMap(args ...interface{}) (map[string]int64, error) {
+	resp, err := c.doReturnStringSlice(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]int64, len(resp)/2)
+	for i := 0; i+1 < len(resp); i += 2 {
+		n, err := strconv.ParseInt(resp[i+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		m[resp[i]] = n
+	}
+	return m, nil
+}
+
+func (c *Client) doReturnBoolMap(args ...interface{}) (map[string]bool, error) {
+	resp, err := c.doReturnIntMap(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]bool, len(resp))
+	for k, v := range resp {
+		m[k] = v != 0
+	}
+	return m, nil
+}
+
 func (c *Client) do(args ...interface{}) ([]string, error) {
+	if c.encryptor != nil {
+		var err error
+		if args, err = c.encryptArgs(args); err != nil {
+			return nil, err
+		}
+	}
+	if c.namespace != "" {
+		args = c.namespaceArgs(args)
+	}
 	err := c.send(args)
 	if err != nil {
 		return nil, err
 	}
 	resp, err := c.recv()
+	if err == nil && c.namespace != "" {
+		resp = c.namespaceResp(args[0], resp)
+	}
+	if err == nil && c.encryptor != nil {
+		resp, err = c.decryptResp(args[0], resp)
+	}
 	return resp, err
 }
 
+// pipeline sends the commands at once, then receives their responses in order.
+func (c *Client) pipeline(cmds [][]interface{}) ([][]string, error) {
+	var buf bytes.Buffer
+	for i, args := range cmds {
+		if c.encryptor != nil {
+			var err error
+			if args, err = c.encryptArgs(args); err != nil {
+				return nil, err
+			}
+		}
+		if c.namespace != "" {
+			args = c.namespaceArgs(args)
+		}
+		cmds[i] = args
+		data, err := formatData(args)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	_, c.err = c.sock.Write(buf.Bytes())
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	// all the responses are received before processed, to keep the connection in sync on errors.
+	resps := make([][]string, len(cmds))
+	for i := range cmds {
+		resp, err := c.recv()
+		if err != nil {
+			return nil, err
+		}
+		resps[i] = resp
+	}
+	for i, args := range cmds {
+		if c.namespace != "" {
+			resps[i] = c.namespaceResp(args[0], resps[i])
+		}
+		if c.encryptor != nil {
+			var err error
+			if resps[i], err = c.decryptResp(args[0], resps[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resps, nil
+}
+
 func (c *Client) send(args []interface{}) error {
 	bytes, err := formatData(args)
 	if err != nil {
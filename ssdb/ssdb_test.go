@@ -1,7 +1,14 @@
 package ssdb
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -19,6 +26,95 @@ func newPool() (*Pool, error) {
 	return NewPool(ServerAddr, ServerPort, Password, 100)
 }
 
+// recordingServer accepts a connection, records the requests and replies "ok 1" to them,
+// or "error" to the commands failing, or the responses set by reply.
+type recordingServer struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	reqs    [][]string
+	replies map[string][]string
+}
+
+// newRecordingServer returns a recordingServer with a Client connected to it.
+func newRecordingServer(t *testing.T, fail ...string) (*recordingServer, *Client) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen failed, err:%v\n", err)
+	}
+	s := &recordingServer{ln: ln, replies: map[string][]string{}}
+	for _, cmd := range fail {
+		s.replies[cmd] = []string{"error"}
+	}
+	go s.serve()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	c, err := Connect(addr.IP.String(), addr.Port)
+	if err != nil {
+		ln.Close()
+		t.Fatalf("Connect failed, err:%v\n", err)
+	}
+	return s, c
+}
+
+func (s *recordingServer) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var req []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			s.mu.Lock()
+			s.reqs = append(s.reqs, req)
+			resp, ok := s.replies[req[0]]
+			s.mu.Unlock()
+			if !ok {
+				resp = []string{"ok", "1"}
+			}
+			var buf bytes.Buffer
+			for _, item := range resp {
+				fmt.Fprintf(&buf, "%d\n%s\n", len(item), item)
+			}
+			buf.WriteByte('\n')
+			conn.Write(buf.Bytes())
+			req = nil
+			continue
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return
+		}
+		data := make([]byte, n+1)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return
+		}
+		req = append(req, string(data[:n]))
+	}
+}
+
+// reply sets the response to the command.
+func (s *recordingServer) reply(cmd string, resp ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[cmd] = resp
+}
+
+// requests returns the requests received since the last call.
+func (s *recordingServer) requests() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reqs := s.reqs
+	s.reqs = nil
+	return reqs
+}
+
 func TestKV(t *testing.T) {
 	p, err := newPool()
 	if err != nil {
@@ -1173,3 +1269,248 @@ func TestQueue(t *testing.T) {
 
 	p.Release(c)
 }
+
+func TestAdmin(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := p.Get()
+
+	err = c.Ping()
+	if err != nil {
+		t.Fatalf("Ping failed, err:%v\n", err)
+	}
+
+	version, err := c.Version()
+	if err != nil {
+		t.Fatalf("Version failed, err:%v\n", err)
+	}
+	t.Logf("Version result:%v\n", version)
+
+	caps, err := c.Capabilities()
+	if err != nil {
+		t.Fatalf("Capabilities failed, err:%v\n", err)
+	}
+	if caps.Version != version {
+		t.Fatalf("Capabilities failed, expected:%v, got:%v\n", version, caps.Version)
+	}
+
+	kr, err := c.KeyRange()
+	if err != nil {
+		t.Fatalf("KeyRange failed, err:%v\n", err)
+	}
+	t.Logf("KeyRange result:%+v\n", kr)
+
+	rule := "10.254.254."
+	err = c.AddAllowIP(rule)
+	if err != nil {
+		t.Fatalf("AddAllowIP failed, err:%v\n", err)
+	}
+
+	rules, err := c.ListAllowIP()
+	if err != nil {
+		t.Fatalf("ListAllowIP failed, err:%v\n", err)
+	}
+	found := false
+	for _, r := range rules {
+		if r == rule {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("ListAllowIP failed, expected:%v in %v\n", rule, rules)
+	}
+
+	err = c.DelAllowIP(rule)
+	if err != nil {
+		t.Fatalf("DelAllowIP failed, err:%v\n", err)
+	}
+
+	err = c.AddDenyIP(rule)
+	if err != nil {
+		t.Fatalf("AddDenyIP failed, err:%v\n", err)
+	}
+
+	rules, err = c.ListDenyIP()
+	if err != nil {
+		t.Fatalf("ListDenyIP failed, err:%v\n", err)
+	}
+	found = false
+	for _, r := range rules {
+		if r == rule {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("ListDenyIP failed, expected:%v in %v\n", rule, rules)
+	}
+
+	err = c.DelDenyIP(rule)
+	if err != nil {
+		t.Fatalf("DelDenyIP failed, err:%v\n", err)
+	}
+
+	p.Release(c)
+}
+
+func TestMultiExists(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := p.Get()
+
+	err = c.Set("me_a", "1")
+	if err != nil {
+		t.Fatalf("Set failed, err:%v\n", err)
+	}
+	c.Del("me_b")
+
+	exists, err := c.MultiExists("me_a", "me_b")
+	if err != nil {
+		t.Fatalf("MultiExists failed, err:%v\n", err)
+	}
+	if !exists["me_a"] || exists["me_b"] {
+		t.Fatalf("MultiExists failed, got:%v\n", exists)
+	}
+
+	name := "me_hash"
+	c.Hclear(name)
+	_, err = c.MultiHset(name, "k1", "v1", "k2", "v2")
+	if err != nil {
+		t.Fatalf("MultiHset failed, err:%v\n", err)
+	}
+
+	exists, err = c.MultiHexists(name, "k1", "k3")
+	if err != nil {
+		t.Fatalf("MultiHexists failed, err:%v\n", err)
+	}
+	if !exists["k1"] || exists["k3"] {
+		t.Fatalf("MultiHexists failed, got:%v\n", exists)
+	}
+
+	sizes, err := c.MultiHsize(name, "me_hash_none")
+	if err != nil {
+		t.Fatalf("MultiHsize failed, err:%v\n", err)
+	}
+	if sizes[name] != 2 || sizes["me_hash_none"] != 0 {
+		t.Fatalf("MultiHsize failed, got:%v\n", sizes)
+	}
+
+	zname := "me_zset"
+	c.Zclear(zname)
+	_, err = c.MultiZset(zname, "k1", 1, "k2", 2, "k3", 3)
+	if err != nil {
+		t.Fatalf("MultiZset failed, err:%v\n", err)
+	}
+
+	exists, err = c.MultiZexists(zname, "k1", "k4")
+	if err != nil {
+		t.Fatalf("MultiZexists failed, err:%v\n", err)
+	}
+	if !exists["k1"] || exists["k4"] {
+		t.Fatalf("MultiZexists failed, got:%v\n", exists)
+	}
+
+	sizes, err = c.MultiZsize(zname, "me_zset_none")
+	if err != nil {
+		t.Fatalf("MultiZsize failed, err:%v\n", err)
+	}
+	if sizes[zname] != 3 || sizes["me_zset_none"] != 0 {
+		t.Fatalf("MultiZsize failed, got:%v\n", sizes)
+	}
+
+	c.Del("me_a")
+	c.Hclear(name)
+	c.Zclear(zname)
+	p.Release(c)
+}
+
+func TestIterator(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := p.Get()
+
+	name := "iter_hash"
+	c.Hclear(name)
+	for i := 0; i < 25; i++ {
+		_, err = c.Hset(name, fmt.Sprintf("k%02d", i), i)
+		if err != nil {
+			t.Fatalf("Hset failed, err:%v\n", err)
+		}
+	}
+
+	count := 0
+	it := c.HscanIter(name, "", "", 10)
+	for it.Next() {
+		expected := fmt.Sprintf("k%02d", count)
+		if it.Key() != expected {
+			t.Fatalf("HscanIter failed, expected:%v, got:%v\n", expected, it.Key())
+		}
+		count++
+	}
+	if it.Err() != nil {
+		t.Fatalf("HscanIter failed, err:%v\n", it.Err())
+	}
+	if count != 25 {
+		t.Fatalf("HscanIter failed, expected:%v, got:%v\n", 25, count)
+	}
+
+	zname := "iter_zset"
+	c.Zclear(zname)
+	for i := 0; i < 25; i++ {
+		// the same score for every 5 keys to test the key cursor.
+		_, err = c.Zset(zname, fmt.Sprintf("k%02d", i), int64(i/5))
+		if err != nil {
+			t.Fatalf("Zset failed, err:%v\n", err)
+		}
+	}
+
+	count = 0
+	zit := c.ZrangeIter(zname, 3, 4)
+	for k := range zit.All() {
+		expected := fmt.Sprintf("k%02d", count+3)
+		if k != expected {
+			t.Fatalf("ZrangeIter failed, expected:%v, got:%v\n", expected, k)
+		}
+		count++
+	}
+	if zit.Err() != nil {
+		t.Fatalf("ZrangeIter failed, err:%v\n", zit.Err())
+	}
+	if count != 22 {
+		t.Fatalf("ZrangeIter failed, expected:%v, got:%v\n", 22, count)
+	}
+
+	qname := "iter_queue"
+	c.Qclear(qname)
+	for i := 0; i < 25; i++ {
+		_, err = c.QpushBack(qname, i)
+		if err != nil {
+			t.Fatalf("QpushBack failed, err:%v\n", err)
+		}
+	}
+
+	count = 0
+	qit := c.QrangeIter(qname, -12, 5)
+	for qit.Next() {
+		count++
+	}
+	if qit.Err() != nil {
+		t.Fatalf("QrangeIter failed, err:%v\n", qit.Err())
+	}
+	if count != 12 {
+		t.Fatalf("QrangeIter failed, expected:%v, got:%v\n", 12, count)
+	}
+
+	c.Hclear(name)
+	c.Zclear(zname)
+	c.Qclear(qname)
+	p.Release(c)
+}
@@ -0,0 +1,486 @@
+package ssdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoGroup is returned if the consumer group of a Stream does not exist.
+var ErrNoGroup = errors.New("no such consumer group")
+
+// StreamLatest makes CreateGroup deliver only the entries added after the group is created.
+const StreamLatest int64 = -1
+
+// streamLockTTL is the lease of the lock of a consumer group, held while reading or claiming.
+const streamLockTTL = 5 * time.Second
+
+// streamGapTimeout is how long a group waits for a missing id before the entries after it,
+// taken by an Add not indexed yet. After that, the id is taken as failed and passed.
+const streamGapTimeout = 10 * time.Second
+
+// Stream is an append-only log of entries like Redis Streams, read by consumer groups.
+// Entries get increasing ids from incr on the key name+":seq", and are kept in the hashmap
+// name+":entries" indexed by the zset name+":index" scored by the ids.
+// Every consumer group has an offset, the last id delivered, in the hashmap name+":groups",
+// and its pending entries, delivered and not acked, in the zset name+":pending:"+group scored
+// by the delivery time in milliseconds, with their consumers and delivery counts in the hashmap
+// name+":pending:"+group+":owners".
+// Reading and claiming of a group are serialized by a lock, since ssdb has no transactions.
+// An entry is indexed a moment after its id is taken, so a group only reads past the ids
+// contiguous to its offset, and a missing id holds the entries after it for streamGapTimeout.
+// The ids deleted by trimming are not missing, the greatest of them is kept in name+":trimmed",
+// and the offsets behind it are moved up to it on reading.
+type Stream struct {
+	r      Runner
+	name   string
+	locker *Locker
+}
+
+// StreamEntry is an entry of a Stream.
+type StreamEntry struct {
+	ID     int64
+	Time   time.Time
+	Values map[string]string
+}
+
+// PendingEntry is an entry delivered to a consumer and not acked yet.
+type PendingEntry struct {
+	ID         int64
+	Consumer   string
+	Deliveries int64
+	// Delivered is when it was delivered last time.
+	Delivered time.Time
+}
+
+type streamRecord struct {
+	Time   int64             `json:"t"`
+	Values map[string]string `json:"v"`
+}
+
+// NewStream returns a Stream on a Client or a Pool.
+func NewStream(r Runner, name string) *Stream {
+	return &Stream{r: r, name: name, locker: NewLocker(r)}
+}
+
+func (s *Stream) key(parts ...string) string {
+	return s.name + ":" + strings.Join(parts, ":")
+}
+
+// Add appends an entry, and returns its id, like XADD.
+func (s *Stream) Add(values map[string]string) (int64, error) {
+	data, err := json.Marshal(streamRecord{Time: time.Now().UnixMilli(), Values: values})
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = s.r.Run(func(c *Client) error {
+		var err error
+		id, err = c.Incr(s.key("seq"), 1)
+		if err != nil {
+			return err
+		}
+		_, err = c.Hset(s.key("entries"), strconv.FormatInt(id, 10), data)
+		if err != nil {
+			return err
+		}
+		_, err = c.Zset(s.key("index"), strconv.FormatInt(id, 10), id)
+		return err
+	})
+	return id, err
+}
+
+// Len returns the number of entries.
+func (s *Stream) Len() (int64, error) {
+	var n int64
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Zsize(s.key("index"))
+		return err
+	})
+	return n, err
+}
+
+// Range returns at most count entries with ids in [start, end], like XRANGE.
+// An end not greater than 0 means no limit.
+func (s *Stream) Range(start, end int64, count int) ([]StreamEntry, error) {
+	var entries []StreamEntry
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		entries, err = s.rangeEntries(c, start, end, count)
+		return err
+	})
+	return entries, err
+}
+
+func (s *Stream) rangeEntries(c *Client, start, end int64, count int) ([]StreamEntry, error) {
+	var scoreEnd interface{} = ""
+	if end > 0 {
+		scoreEnd = end
+	}
+	resp, err := c.doReturnRange("zscan", s.key("index"), "", start, scoreEnd, count)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resp)/2)
+	for i := 0; i+1 < len(resp); i += 2 {
+		ids = append(ids, resp[i])
+	}
+	return s.entries(c, ids)
+}
+
+// entries returns the entries of the ids in order, the ids not existed are skipped.
+func (s *Stream) entries(c *Client, ids []string) ([]StreamEntry, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	resp, err := c.unpackPairs(c.doReturnRange("multi_hget", s.key("entries"), ids))
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]string, len(resp)/2)
+	for i := 0; i+1 < len(resp); i += 2 {
+		records[resp[i]] = resp[i+1]
+	}
+
+	entries := make([]StreamEntry, 0, len(ids))
+	for _, id := range ids {
+		data, ok := records[id]
+		if !ok {
+			continue
+		}
+		var rec streamRecord
+		if err := json.Unmarshal([]byte(data), &rec); err != nil {
+			return nil, err
+		}
+		n, _ := strconv.ParseInt(id, 10, 64)
+		entries = append(entries, StreamEntry{ID: n, Time: time.UnixMilli(rec.Time), Values: rec.Values})
+	}
+	return entries, nil
+}
+
+// TrimMaxLen deletes the oldest entries to keep at most maxLen entries, like XTRIM MAXLEN,
+// and returns the number of entries deleted.
+func (s *Stream) TrimMaxLen(maxLen int64) (int64, error) {
+	var deleted int64
+	err := s.r.Run(func(c *Client) error {
+		size, err := c.Zsize(s.key("index"))
+		if err != nil {
+			return err
+		}
+		for size-deleted > maxLen {
+			n := min(size-deleted-maxLen, 1000)
+			resp, err := c.doReturnRange("zrange", s.key("index"), 0, n)
+			if err != nil {
+				return err
+			}
+			if len(resp) == 0 {
+				return nil
+			}
+			if err = s.delete(c, resp); err != nil {
+				return err
+			}
+			deleted += int64(len(resp) / 2)
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// TrimMinID deletes the entries with ids less than minID, like XTRIM MINID,
+// and returns the number of entries deleted.
+func (s *Stream) TrimMinID(minID int64) (int64, error) {
+	var deleted int64
+	err := s.r.Run(func(c *Client) error {
+		for {
+			resp, err := c.doReturnRange("zscan", s.key("index"), "", "", minID-1, 1000)
+			if err != nil {
+				return err
+			}
+			if len(resp) == 0 {
+				return nil
+			}
+			if err = s.delete(c, resp); err != nil {
+				return err
+			}
+			deleted += int64(len(resp) / 2)
+		}
+	})
+	return deleted, err
+}
+
+// delete deletes the entries of the id-score pairs from the index, in the order of ids,
+// and raises the trimmed marker to the last id.
+func (s *Stream) delete(c *Client, pairs []string) error {
+	ids := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		ids = append(ids, pairs[i])
+	}
+	if _, err := c.doReturnInt("multi_hdel", s.key("entries"), ids); err != nil {
+		return err
+	}
+	if _, err := c.doReturnInt("multi_zdel", s.key("index"), ids); err != nil {
+		return err
+	}
+
+	last, err := strconv.ParseInt(ids[len(ids)-1], 10, 64)
+	if err != nil {
+		return err
+	}
+	trimmed, err := s.trimmed(c)
+	if err != nil || trimmed >= last {
+		return err
+	}
+	// not atomic, a marker lowered by a concurrent trim only makes the groups wait for the gap.
+	return c.Set(s.key("trimmed"), last)
+}
+
+// trimmed returns the greatest id deleted by trimming, 0 if none.
+func (s *Stream) trimmed(c *Client) (int64, error) {
+	v, err := c.Get(s.key("trimmed"))
+	if err != nil {
+		if err.Error() == "not_found" {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// CreateGroup creates a consumer group delivering the entries after startID,
+// 0 for all the entries, or StreamLatest for the entries added from now on.
+// It does nothing if the group exists.
+func (s *Stream) CreateGroup(group string, startID int64) error {
+	return s.r.Run(func(c *Client) error {
+		n, err := c.Hexists(s.key("groups"), group)
+		if err != nil || n == 1 {
+			return err
+		}
+		if startID == StreamLatest {
+			v, err := c.Get(s.key("seq"))
+			if err != nil && err.Error() != "not_found" {
+				return err
+			}
+			startID, _ = strconv.ParseInt(v, 10, 64)
+		}
+		_, err = c.Hset(s.key("groups"), group, startID)
+		return err
+	})
+}
+
+// DeleteGroup deletes a consumer group with its pending entries.
+func (s *Stream) DeleteGroup(group string) error {
+	return s.r.Run(func(c *Client) error {
+		if _, err := c.Hdel(s.key("groups"), group); err != nil {
+			return err
+		}
+		if _, err := c.Zclear(s.key("pending", group)); err != nil {
+			return err
+		}
+		_, err := c.Hclear(s.key("pending", group, "owners"))
+		return err
+	})
+}
+
+// withGroup runs f holding the lock of the group, with the offset of the group.
+func (s *Stream) withGroup(group string, f func(c *Client, offset int64) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), streamLockTTL)
+	defer cancel()
+	lk, err := s.locker.Lock(ctx, s.key("lock", group), streamLockTTL)
+	if err != nil {
+		return err
+	}
+	defer lk.Unlock()
+
+	return s.r.Run(func(c *Client) error {
+		v, err := c.Hget(s.key("groups"), group)
+		if err != nil {
+			if err.Error() == "not_found" {
+				return ErrNoGroup
+			}
+			return err
+		}
+		offset, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		return f(c, offset)
+	})
+}
+
+// ReadGroup delivers at most count new entries of the group to the consumer, like XREADGROUP with ">".
+// The entries are pending until they are acked by Ack.
+func (s *Stream) ReadGroup(group, consumer string, count int) ([]StreamEntry, error) {
+	var entries []StreamEntry
+	err := s.withGroup(group, func(c *Client, offset int64) error {
+		// the ids trimmed are not waited for.
+		trimmed, err := s.trimmed(c)
+		if err != nil {
+			return err
+		}
+		offset = max(offset, trimmed)
+		entries, err = s.rangeEntries(c, offset+1, 0, count)
+		if err != nil {
+			return err
+		}
+		entries = contiguousEntries(offset, entries, time.Now())
+		if len(entries) == 0 {
+			return nil
+		}
+
+		now := time.Now().UnixMilli()
+		pending := make([]interface{}, 0, len(entries)*2)
+		owners := make([]interface{}, 0, len(entries)*2)
+		for _, e := range entries {
+			id := strconv.FormatInt(e.ID, 10)
+			pending = append(pending, id, now)
+			owners = append(owners, id, "1:"+consumer)
+		}
+		if _, err = c.MultiHset(s.key("pending", group, "owners"), owners...); err != nil {
+			return err
+		}
+		if _, err = c.MultiZset(s.key("pending", group), pending...); err != nil {
+			return err
+		}
+		_, err = c.Hset(s.key("groups"), group, entries[len(entries)-1].ID)
+		return err
+	})
+	return entries, err
+}
+
+// contiguousEntries returns the entries with ids contiguous from offset+1, the entries after
+// a missing id are dropped, unless the entry after it was added streamGapTimeout before now.
+func contiguousEntries(offset int64, entries []StreamEntry, now time.Time) []StreamEntry {
+	next := offset + 1
+	for i, e := range entries {
+		if e.ID != next && now.Sub(e.Time) < streamGapTimeout {
+			return entries[:i]
+		}
+		next = e.ID + 1
+	}
+	return entries
+}
+
+// Ack removes the entries from the pending entries of the group, and returns the number removed.
+func (s *Stream) Ack(group string, ids ...int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = strconv.FormatInt(id, 10)
+	}
+
+	var n int64
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.doReturnInt("multi_zdel", s.key("pending", group), keys)
+		if err != nil {
+			return err
+		}
+		_, err = c.doReturnInt("multi_hdel", s.key("pending", group, "owners"), keys)
+		return err
+	})
+	return n, err
+}
+
+// Pending returns at most count pending entries of the group, delivered longest ago first.
+func (s *Stream) Pending(group string, count int) ([]PendingEntry, error) {
+	var pending []PendingEntry
+	err := s.r.Run(func(c *Client) error {
+		var err error
+		pending, err = s.pending(c, group, "", count)
+		return err
+	})
+	return pending, err
+}
+
+// pending returns the pending entries delivered not after before, in milliseconds, or empty for no limit.
+func (s *Stream) pending(c *Client, group string, before interface{}, count int) ([]PendingEntry, error) {
+	resp, err := c.doReturnRange("zscan", s.key("pending", group), "", "", before, count)
+	if err != nil || len(resp) == 0 {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resp)/2)
+	for i := 0; i+1 < len(resp); i += 2 {
+		ids = append(ids, resp[i])
+	}
+	owners, err := c.unpackPairs(c.doReturnRange("multi_hget", s.key("pending", group, "owners"), ids))
+	if err != nil {
+		return nil, err
+	}
+	owner := make(map[string]string, len(owners)/2)
+	for i := 0; i+1 < len(owners); i += 2 {
+		owner[owners[i]] = owners[i+1]
+	}
+
+	pending := make([]PendingEntry, 0, len(ids))
+	for i := 0; i+1 < len(resp); i += 2 {
+		id, _ := strconv.ParseInt(resp[i], 10, 64)
+		ms, _ := strconv.ParseInt(resp[i+1], 10, 64)
+		p := PendingEntry{ID: id, Delivered: time.UnixMilli(ms)}
+		deliveries, consumer, _ := strings.Cut(owner[resp[i]], ":")
+		p.Deliveries, _ = strconv.ParseInt(deliveries, 10, 64)
+		p.Consumer = consumer
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// Claim transfers at most count pending entries of the group, delivered at least minIdle ago,
+// to the consumer, and returns them, like XAUTOCLAIM. The entries deleted by trimming are
+// removed from the pending entries.
+func (s *Stream) Claim(group, consumer string, minIdle time.Duration, count int) ([]StreamEntry, error) {
+	var entries []StreamEntry
+	err := s.withGroup(group, func(c *Client, offset int64) error {
+		now := time.Now()
+		stale, err := s.pending(c, group, now.Add(-minIdle).UnixMilli(), count)
+		if err != nil || len(stale) == 0 {
+			return err
+		}
+		ids := make([]string, len(stale))
+		for i, p := range stale {
+			ids[i] = strconv.FormatInt(p.ID, 10)
+		}
+		entries, err = s.entries(c, ids)
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[int64]bool, len(entries))
+		for _, e := range entries {
+			existing[e.ID] = true
+		}
+		var pending, owners []interface{}
+		var gone []string
+		for i, p := range stale {
+			if !existing[p.ID] {
+				gone = append(gone, ids[i])
+				continue
+			}
+			pending = append(pending, ids[i], now.UnixMilli())
+			owners = append(owners, ids[i], strconv.FormatInt(p.Deliveries+1, 10)+":"+consumer)
+		}
+		if len(gone) > 0 {
+			if _, err = c.doReturnInt("multi_zdel", s.key("pending", group), gone); err != nil {
+				return err
+			}
+			if _, err = c.doReturnInt("multi_hdel", s.key("pending", group, "owners"), gone); err != nil {
+				return err
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if _, err = c.MultiHset(s.key("pending", group, "owners"), owners...); err != nil {
+			return err
+		}
+		_, err = c.MultiZset(s.key("pending", group), pending...)
+		return err
+	})
+	return entries, err
+}
@@ -0,0 +1,211 @@
+package ssdb
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "stream_test"
+	s := NewStream(p, name)
+	defer func() {
+		s.DeleteGroup("g")
+		c := p.Get()
+		c.Del(name + ":seq")
+		c.Del(name + ":trimmed")
+		c.Hclear(name + ":entries")
+		c.Zclear(name + ":index")
+		c.Hclear(name + ":groups")
+		p.Release(c)
+	}()
+
+	var ids []int64
+	for _, v := range []string{"a", "b", "c", "d"} {
+		id, err := s.Add(map[string]string{"v": v})
+		if err != nil {
+			t.Fatalf("Add failed, err:%v\n", err)
+		}
+		if len(ids) > 0 && id <= ids[len(ids)-1] {
+			t.Fatalf("Add failed, id %v not increasing\n", id)
+		}
+		ids = append(ids, id)
+	}
+
+	entries, err := s.Range(ids[1], ids[2], 10)
+	if err != nil || len(entries) != 2 || entries[0].Values["v"] != "b" || entries[1].ID != ids[2] {
+		t.Fatalf("Range failed, got:%v, err:%v\n", entries, err)
+	}
+
+	_, err = s.ReadGroup("g", "c1", 10)
+	if err != ErrNoGroup {
+		t.Fatalf("ReadGroup failed, expected:%v, got:%v\n", ErrNoGroup, err)
+	}
+	if err := s.CreateGroup("g", ids[0]); err != nil {
+		t.Fatalf("CreateGroup failed, err:%v\n", err)
+	}
+	entries, err = s.ReadGroup("g", "c1", 2)
+	if err != nil || len(entries) != 2 || entries[0].ID != ids[1] {
+		t.Fatalf("ReadGroup failed, got:%v, err:%v\n", entries, err)
+	}
+	entries, err = s.ReadGroup("g", "c2", 10)
+	if err != nil || len(entries) != 1 || entries[0].ID != ids[3] {
+		t.Fatalf("ReadGroup failed, got:%v, err:%v\n", entries, err)
+	}
+
+	n, err := s.Ack("g", ids[1], ids[3])
+	if err != nil || n != 2 {
+		t.Fatalf("Ack failed, expected:2, got:%v, err:%v\n", n, err)
+	}
+	pending, err := s.Pending("g", 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != ids[2] || pending[0].Consumer != "c1" {
+		t.Fatalf("Pending failed, got:%v, err:%v\n", pending, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	entries, err = s.Claim("g", "c2", 10*time.Millisecond, 10)
+	if err != nil || len(entries) != 1 || entries[0].ID != ids[2] {
+		t.Fatalf("Claim failed, got:%v, err:%v\n", entries, err)
+	}
+	pending, err = s.Pending("g", 10)
+	if err != nil || len(pending) != 1 || pending[0].Consumer != "c2" || pending[0].Deliveries != 2 {
+		t.Fatalf("Pending failed, got:%v, err:%v\n", pending, err)
+	}
+
+	deleted, err := s.TrimMaxLen(2)
+	if err != nil || deleted != 2 {
+		t.Fatalf("TrimMaxLen failed, expected:2, got:%v, err:%v\n", deleted, err)
+	}
+	deleted, err = s.TrimMinID(ids[3])
+	if err != nil || deleted != 1 {
+		t.Fatalf("TrimMinID failed, expected:1, got:%v, err:%v\n", deleted, err)
+	}
+	size, err := s.Len()
+	if err != nil || size != 1 {
+		t.Fatalf("Len failed, expected:1, got:%v, err:%v\n", size, err)
+	}
+}
+
+func TestStreamContiguous(t *testing.T) {
+	now := time.Now()
+	fresh, stale := now.Add(-time.Second), now.Add(-2*streamGapTimeout)
+	tests := []struct {
+		offset   int64
+		entries  []StreamEntry
+		expected int
+	}{
+		{0, []StreamEntry{{ID: 1, Time: fresh}, {ID: 2, Time: fresh}}, 2},
+		{0, []StreamEntry{{ID: 1, Time: fresh}, {ID: 3, Time: fresh}}, 1},
+		{0, []StreamEntry{{ID: 2, Time: fresh}}, 0},
+		{0, []StreamEntry{{ID: 1, Time: stale}, {ID: 3, Time: stale}, {ID: 5, Time: fresh}}, 2},
+		{5, []StreamEntry{{ID: 9, Time: stale}, {ID: 10, Time: fresh}}, 2},
+	}
+	for i, tt := range tests {
+		if got := contiguousEntries(tt.offset, tt.entries, now); len(got) != tt.expected {
+			t.Fatalf("contiguousEntries failed at %v, expected %v entries, got:%v\n", i, tt.expected, got)
+		}
+	}
+}
+
+func TestStreamGap(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "stream_gap_test"
+	s := NewStream(p, name)
+	defer func() {
+		s.DeleteGroup("g")
+		c := p.Get()
+		c.Del(name + ":seq")
+		c.Del(name + ":trimmed")
+		c.Hclear(name + ":entries")
+		c.Zclear(name + ":index")
+		c.Hclear(name + ":groups")
+		p.Release(c)
+	}()
+	if err := s.CreateGroup("g", 0); err != nil {
+		t.Fatalf("CreateGroup failed, err:%v\n", err)
+	}
+
+	first, err := s.Add(map[string]string{"v": "a"})
+	if err != nil {
+		t.Fatalf("Add failed, err:%v\n", err)
+	}
+	// an Add taking its id, not indexed yet.
+	c := p.Get()
+	slow, err := c.Incr(name+":seq", 1)
+	p.Release(c)
+	if err != nil {
+		t.Fatalf("Incr failed, err:%v\n", err)
+	}
+	if _, err = s.Add(map[string]string{"v": "c"}); err != nil {
+		t.Fatalf("Add failed, err:%v\n", err)
+	}
+
+	entries, err := s.ReadGroup("g", "c1", 10)
+	if err != nil || len(entries) != 1 || entries[0].ID != first {
+		t.Fatalf("ReadGroup failed, expected only %v, got:%v, err:%v\n", first, entries, err)
+	}
+
+	// the slow Add finishes.
+	data, _ := json.Marshal(streamRecord{Time: time.Now().UnixMilli(), Values: map[string]string{"v": "b"}})
+	c = p.Get()
+	_, err = c.Hset(name+":entries", strconv.FormatInt(slow, 10), data)
+	if err == nil {
+		_, err = c.Zset(name+":index", strconv.FormatInt(slow, 10), slow)
+	}
+	p.Release(c)
+	if err != nil {
+		t.Fatalf("Hset failed, err:%v\n", err)
+	}
+	entries, err = s.ReadGroup("g", "c1", 10)
+	if err != nil || len(entries) != 2 || entries[0].Values["v"] != "b" || entries[1].Values["v"] != "c" {
+		t.Fatalf("ReadGroup failed, got:%v, err:%v\n", entries, err)
+	}
+}
+
+func TestStreamTrimmedGap(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "stream_trimmed_test"
+	s := NewStream(p, name)
+	defer func() {
+		s.DeleteGroup("g")
+		c := p.Get()
+		c.Del(name + ":seq")
+		c.Del(name + ":trimmed")
+		c.Hclear(name + ":entries")
+		c.Zclear(name + ":index")
+		c.Hclear(name + ":groups")
+		p.Release(c)
+	}()
+	if err := s.CreateGroup("g", 0); err != nil {
+		t.Fatalf("CreateGroup failed, err:%v\n", err)
+	}
+
+	var last int64
+	for _, v := range []string{"a", "b", "c"} {
+		if last, err = s.Add(map[string]string{"v": v}); err != nil {
+			t.Fatalf("Add failed, err:%v\n", err)
+		}
+	}
+	// trimmed past the offset of the slow group.
+	if n, err := s.TrimMaxLen(1); err != nil || n != 2 {
+		t.Fatalf("TrimMaxLen failed, expected:2, got:%v, err:%v\n", n, err)
+	}
+	entries, err := s.ReadGroup("g", "c1", 10)
+	if err != nil || len(entries) != 1 || entries[0].ID != last {
+		t.Fatalf("ReadGroup failed, expected only %v, got:%v, err:%v\n", last, entries, err)
+	}
+}
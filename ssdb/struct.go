@@ -0,0 +1,261 @@
+package ssdb
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// structField describes a struct field stored as a key of a hashmap.
+type structField struct {
+	// name is the key in the hashmap.
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// fieldCache caches the []structField of struct types.
+var fieldCache sync.Map
+
+// structFields returns the fields stored in a hashmap for the struct type.
+// Fields are named by the tag like `ssdb:"name,omitempty"`, or the field name if no tag,
+// and skipped if the tag is "-". Embedded structs are flattened.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("ssdb")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && tag == "" && !isTextType(f.Type) {
+			for _, ef := range structFields(f.Type) {
+				ef.index = append([]int{i}, ef.index...)
+				fields = append(fields, ef)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, structField{
+			name:      name,
+			index:     f.Index,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isTextType reports whether the type encodes itself as text, like time.Time.
+func isTextType(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// structValue returns the struct v points to, or a copy of v if it's a struct.
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("unsupported data type %T, expected a struct", v)
+	}
+	if !rv.CanAddr() {
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		rv = cp
+	}
+	return rv, nil
+}
+
+// encodeStruct encodes the fields of the struct to key-value pairs.
+func encodeStruct(v interface{}) ([]interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []interface{}
+	for _, f := range structFields(rv.Type()) {
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		s, err := formatField(fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.name, err)
+		}
+		kvs = append(kvs, f.name, s)
+	}
+	return kvs, nil
+}
+
+// decodeStruct sets the fields of the struct v points to by the values looked up.
+// Fields absent in m are left unchanged.
+func decodeStruct(m OrderedMap, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported data type %T, expected a pointer to struct", v)
+	}
+	rv = rv.Elem()
+
+	for _, f := range structFields(rv.Type()) {
+		s, ok := m.Lookup(f.name)
+		if !ok {
+			continue
+		}
+		err := parseField(rv.FieldByIndex(f.index), s)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+// formatField formats a field value, TextMarshaler and []byte are supported besides the built-in values.
+func formatField(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return string(v.Bytes()), nil
+	}
+	// formatAtom rounds floats to 10 decimal places, format them losslessly instead.
+	if k := v.Kind(); k == reflect.Float32 || k == reflect.Float64 {
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return formatAtom(v)
+}
+
+// parseField parses s into the field value.
+func parseField(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return parseField(v.Elem(), s)
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported data type %v", v.Type())
+		}
+		v.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported data type %v", v.Type())
+	}
+	return nil
+}
+
+// HsetStruct stores the fields of a struct as key-value pairs of a hashmap by multi_hset,
+// v is a struct or a pointer to struct, and the number of keys set is returned.
+// The keys are named by tags like `ssdb:"key"`, or the field names if no tag.
+// Built-in values, []byte and types implementing encoding.TextMarshaler like time.Time are supported.
+// Fields tagged with omitempty are skipped if zero, and nil pointers are always skipped.
+func (c *Client) HsetStruct(name string, v interface{}) (int64, error) {
+	kvs, err := encodeStruct(v)
+	if err != nil {
+		return 0, err
+	}
+	if len(kvs) == 0 {
+		return 0, nil
+	}
+	return c.MultiHset(name, kvs...)
+}
+
+// HgetallInto reads the whole hashmap into the struct v points to, see HsetStruct for the keys.
+// The keys without related fields are ignored, and the fields without related keys are left unchanged.
+func (c *Client) HgetallInto(name string, v interface{}) error {
+	m, err := c.Hgetall(name)
+	if err != nil {
+		return err
+	}
+	return decodeStruct(m, v)
+}
+
+// HgetInto reads only the keys related to the fields of the struct v points to by multi_hget.
+// If keys are provided, only these keys are read.
+func (c *Client) HgetInto(name string, v interface{}, keys ...string) error {
+	if len(keys) == 0 {
+		rv, err := structValue(v)
+		if err != nil {
+			return err
+		}
+		for _, f := range structFields(rv.Type()) {
+			keys = append(keys, f.name)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	resp, err := c.unpackPairs(c.doReturnStringSlice("multi_hget", name, keys))
+	if err != nil {
+		return err
+	}
+	return decodeStruct(newMap(resp), v)
+}
@@ -0,0 +1,118 @@
+package ssdb
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type structBase struct {
+	ID int64 `ssdb:"id"`
+}
+
+type structUser struct {
+	structBase
+	Name     string    `ssdb:"name"`
+	Nick     string    `ssdb:"nick,omitempty"`
+	Age      uint8     `ssdb:"age"`
+	Score    float64   `ssdb:"score"`
+	Admin    bool      `ssdb:"admin"`
+	Created  time.Time `ssdb:"created"`
+	Avatar   []byte    `ssdb:"avatar"`
+	IP       net.IP    `ssdb:"ip"`
+	Parent   *int64    `ssdb:"parent"`
+	Ignored  string    `ssdb:"-"`
+	Untagged string
+	secret   string
+}
+
+func TestStructCodec(t *testing.T) {
+	parent := int64(7)
+	u := structUser{
+		structBase: structBase{ID: 42},
+		Name:       "gossdb",
+		Age:        18,
+		Score:      99.5,
+		Admin:      true,
+		Created:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Avatar:     []byte{0, 1, 2},
+		IP:         net.ParseIP("10.0.0.1"),
+		Parent:     &parent,
+		Ignored:    "ignored",
+		Untagged:   "untagged",
+		secret:     "secret",
+	}
+
+	kvs, err := encodeStruct(u)
+	if err != nil {
+		t.Fatalf("encodeStruct failed, err:%v\n", err)
+	}
+	var s []string
+	for _, kv := range kvs {
+		s = append(s, kv.(string))
+	}
+	m := newMap(s)
+	if _, ok := m.Lookup("nick"); ok {
+		t.Fatalf("encodeStruct failed, omitempty field encoded\n")
+	}
+	if _, ok := m.Lookup("Ignored"); ok {
+		t.Fatalf("encodeStruct failed, ignored field encoded\n")
+	}
+	if v, _ := m.Lookup("admin"); v != "1" {
+		t.Fatalf("encodeStruct failed, expected:%v, got:%v\n", "1", v)
+	}
+
+	var got structUser
+	err = decodeStruct(m, &got)
+	if err != nil {
+		t.Fatalf("decodeStruct failed, err:%v\n", err)
+	}
+	if got.ID != u.ID || got.Name != u.Name || got.Age != u.Age || got.Score != u.Score ||
+		got.Admin != u.Admin || !got.Created.Equal(u.Created) || string(got.Avatar) != string(u.Avatar) ||
+		!got.IP.Equal(u.IP) || got.Parent == nil || *got.Parent != parent || got.Untagged != u.Untagged {
+		t.Fatalf("decodeStruct failed, expected:%+v, got:%+v\n", u, got)
+	}
+	if got.Ignored != "" || got.secret != "" {
+		t.Fatalf("decodeStruct failed, unexpected fields decoded:%+v\n", got)
+	}
+
+	err = decodeStruct(newMap([]string{"age", "x"}), &got)
+	if err == nil {
+		t.Fatalf("decodeStruct failed, expected error for bad value\n")
+	}
+
+	err = decodeStruct(m, got)
+	if err == nil {
+		t.Fatalf("decodeStruct failed, expected error for non-pointer\n")
+	}
+}
+
+func TestStructFloats(t *testing.T) {
+	type floats struct {
+		Small float64 `ssdb:"small"`
+		Large float64 `ssdb:"large"`
+		Tenth float32 `ssdb:"tenth"`
+	}
+	f := floats{Small: 1.23456789012345e-7, Large: 1e300, Tenth: 0.1}
+
+	kvs, err := encodeStruct(f)
+	if err != nil {
+		t.Fatalf("encodeStruct failed, err:%v\n", err)
+	}
+	var s []string
+	for _, kv := range kvs {
+		s = append(s, kv.(string))
+	}
+	m := newMap(s)
+	if v, _ := m.Lookup("tenth"); v != "0.1" {
+		t.Fatalf("encodeStruct failed, expected:%v, got:%v\n", "0.1", v)
+	}
+
+	var got floats
+	if err = decodeStruct(m, &got); err != nil {
+		t.Fatalf("decodeStruct failed, err:%v\n", err)
+	}
+	if got != f {
+		t.Fatalf("decodeStruct failed, expected:%+v, got:%+v\n", f, got)
+	}
+}
@@ -0,0 +1,310 @@
+package ssdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeSeriesStorage is how a TimeSeries stores the points.
+type TimeSeriesStorage int
+
+const (
+	// HashStorage stores the points in a hashmap per bucket of time, keyed by their timestamps.
+	// Old points are dropped a whole bucket at a time by hclear.
+	HashStorage TimeSeriesStorage = iota
+	// ZsetStorage stores the points in a zset per series, scored by their timestamps.
+	// Old points are dropped by zremrangebyscore.
+	ZsetStorage
+)
+
+// Aggregation combines the values of points in a downsampling step.
+type Aggregation int
+
+// The aggregations of the points in a step.
+const (
+	AggAvg Aggregation = iota
+	AggSum
+	AggMin
+	AggMax
+	AggCount
+)
+
+// TimeSeriesOptions configures a TimeSeries.
+type TimeSeriesOptions struct {
+	// Storage is HashStorage by default.
+	Storage TimeSeriesStorage
+	// Bucket is the span of time of a hashmap for HashStorage, 1 hour by default.
+	Bucket time.Duration
+	// Retention is how long the points are kept by Trim, 0 to keep them forever.
+	Retention time.Duration
+}
+
+// Point is a value at a time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Sample is a point of a series, for batch writes.
+type Sample struct {
+	Series string
+	Point
+}
+
+// TimeSeries stores the points of series, with the timestamps in Unix milliseconds.
+// For HashStorage, the hashmap of the bucket starting at b, in Unix seconds, is named
+// prefix+series+":"+b padded to 11 digits. For ZsetStorage, the zset is named prefix+series,
+// and the keys are the timestamps and values of the points, so the points with the same time
+// and different values are both kept.
+// Points before the Unix epoch are not supported.
+type TimeSeries struct {
+	r      Runner
+	prefix string
+	opts   TimeSeriesOptions
+}
+
+// NewTimeSeries returns a TimeSeries on a Client or a Pool, opts may be nil for the defaults.
+func NewTimeSeries(r Runner, prefix string, opts *TimeSeriesOptions) *TimeSeries {
+	ts := &TimeSeries{r: r, prefix: prefix}
+	if opts != nil {
+		ts.opts = *opts
+	}
+	if ts.opts.Bucket < time.Second {
+		ts.opts.Bucket = time.Hour
+	}
+	return ts
+}
+
+// Add writes a point of series.
+func (ts *TimeSeries) Add(series string, t time.Time, value float64) error {
+	return ts.AddMulti([]Sample{{Series: series, Point: Point{Time: t, Value: value}}})
+}
+
+// AddMulti writes the points of many series, pipelined in batches.
+func (ts *TimeSeries) AddMulti(samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	cmds := make([][]interface{}, len(samples))
+	for i, s := range samples {
+		ms := s.Time.UnixMilli()
+		if ms < 0 {
+			return fmt.Errorf("time %v before the Unix epoch", s.Time)
+		}
+		v := strconv.FormatFloat(s.Value, 'g', -1, 64)
+		if ts.opts.Storage == ZsetStorage {
+			cmds[i] = []interface{}{"zset", ts.prefix + s.Series, pointKey(ms) + ":" + v, ms}
+		} else {
+			cmds[i] = []interface{}{"hset", ts.bucketName(s.Series, ms), pointKey(ms), v}
+		}
+	}
+
+	return ts.r.Run(func(c *Client) error {
+		for len(cmds) > 0 {
+			n := min(len(cmds), 1000)
+			resps, err := c.pipeline(cmds[:n])
+			if err != nil {
+				return err
+			}
+			for _, resp := range resps {
+				if len(resp) == 0 {
+					return errors.New("no response received")
+				}
+				if resp[0] != "ok" {
+					return errors.New(resp[0])
+				}
+			}
+			cmds = cmds[n:]
+		}
+		return nil
+	})
+}
+
+// Range returns the points of series in [from, to], ordered by time.
+func (ts *TimeSeries) Range(series string, from, to time.Time) ([]Point, error) {
+	var points []Point
+	err := ts.scan(series, from, to, func(p Point) {
+		points = append(points, p)
+	})
+	return points, err
+}
+
+// Downsample aggregates the points of series in [from, to] by steps of step aligned to the Unix epoch,
+// and returns a point per step having points, at the start of the step.
+func (ts *TimeSeries) Downsample(series string, from, to time.Time, step time.Duration, agg Aggregation) ([]Point, error) {
+	stepMs := step.Milliseconds()
+	if stepMs <= 0 {
+		return nil, errors.New("step must be at least 1 millisecond")
+	}
+
+	type acc struct {
+		sum, min, max float64
+		count         int64
+	}
+	accs := map[int64]*acc{}
+	err := ts.scan(series, from, to, func(p Point) {
+		start := p.Time.UnixMilli() / stepMs * stepMs
+		a := accs[start]
+		if a == nil {
+			a = &acc{min: math.Inf(1), max: math.Inf(-1)}
+			accs[start] = a
+		}
+		a.sum += p.Value
+		a.min = math.Min(a.min, p.Value)
+		a.max = math.Max(a.max, p.Value)
+		a.count++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(accs))
+	for start, a := range accs {
+		p := Point{Time: time.UnixMilli(start)}
+		switch agg {
+		case AggSum:
+			p.Value = a.sum
+		case AggMin:
+			p.Value = a.min
+		case AggMax:
+			p.Value = a.max
+		case AggCount:
+			p.Value = float64(a.count)
+		default:
+			p.Value = a.sum / float64(a.count)
+		}
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points, nil
+}
+
+// scan calls f with the points of series in [from, to] in order.
+func (ts *TimeSeries) scan(series string, from, to time.Time, f func(p Point)) error {
+	start, end := max(from.UnixMilli(), 0), to.UnixMilli()
+	if start > end {
+		return nil
+	}
+
+	return ts.r.Run(func(c *Client) error {
+		if ts.opts.Storage == ZsetStorage {
+			it := c.ZkeysIter(ts.prefix+series, "", start, end, 1000)
+			for it.Next() {
+				k, v, _ := strings.Cut(it.Key(), ":")
+				p, err := parsePoint(k, v)
+				if err != nil {
+					return err
+				}
+				f(p)
+			}
+			return it.Err()
+		}
+
+		bucket := ts.opts.Bucket.Milliseconds()
+		for b := start / bucket * bucket; b <= end; b += bucket {
+			it := c.HscanIter(ts.bucketName(series, b), pointKey(start-1), pointKey(end), 1000)
+			for it.Next() {
+				p, err := parsePoint(it.Key(), it.Value())
+				if err != nil {
+					return err
+				}
+				f(p)
+			}
+			if err := it.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Trim drops the points older than the retention of the series, for HashStorage,
+// only the buckets ending before the retention are dropped.
+// It should be called periodically, or by RunRetention.
+func (ts *TimeSeries) Trim(series ...string) error {
+	if ts.opts.Retention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-ts.opts.Retention).UnixMilli()
+
+	return ts.r.Run(func(c *Client) error {
+		for _, s := range series {
+			if ts.opts.Storage == ZsetStorage {
+				_, err := c.Zremrangebyscore(ts.prefix+s, 0, cutoff-1)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			// the buckets before the bucket of cutoff, their names are ordered by time.
+			bucket := ts.opts.Bucket.Milliseconds()
+			last := ts.bucketName(s, cutoff/bucket*bucket-bucket)
+			for {
+				names, err := c.doReturnRange("hlist", ts.prefix+s+":", last, 100)
+				if err != nil {
+					return err
+				}
+				for _, name := range names {
+					if _, err := c.Hclear(name); err != nil {
+						return err
+					}
+				}
+				if len(names) < 100 {
+					break
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// RunRetention trims the series every interval, until ctx is done or the server fails.
+func (ts *TimeSeries) RunRetention(ctx context.Context, interval time.Duration, series ...string) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := ts.Trim(series...); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// bucketName returns the name of the hashmap of the bucket including ms.
+func (ts *TimeSeries) bucketName(series string, ms int64) string {
+	bucket := ts.opts.Bucket.Milliseconds()
+	return fmt.Sprintf("%s%s:%011d", ts.prefix, series, ms/bucket*bucket/1000)
+}
+
+// pointKey formats the timestamp padded, so the keys are ordered by time.
+func pointKey(ms int64) string {
+	if ms < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%013d", ms)
+}
+
+func parsePoint(key, value string) (Point, error) {
+	ms, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("bad timestamp %q", key)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("bad value %q at %v", value, key)
+	}
+	return Point{Time: time.UnixMilli(ms), Value: v}, nil
+}
@@ -0,0 +1,67 @@
+package ssdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesKeys(t *testing.T) {
+	ts := NewTimeSeries(nil, "ts:", nil)
+	at := time.UnixMilli(1706700000123)
+	if name := ts.bucketName("cpu", at.UnixMilli()); name != "ts:cpu:01706698800" {
+		t.Fatalf("bucketName failed, got:%v\n", name)
+	}
+	if key := pointKey(at.UnixMilli()); key != "1706700000123" {
+		t.Fatalf("pointKey failed, got:%v\n", key)
+	}
+	p, err := parsePoint("0000000001500", "2.5")
+	if err != nil || !p.Time.Equal(time.UnixMilli(1500)) || p.Value != 2.5 {
+		t.Fatalf("parsePoint failed, got:%+v, err:%v\n", p, err)
+	}
+}
+
+func TestTimeSeries(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.UnixMilli(time.Now().Truncate(time.Hour).Add(-90 * time.Minute).UnixMilli())
+	for _, storage := range []TimeSeriesStorage{HashStorage, ZsetStorage} {
+		ts := NewTimeSeries(p, "timeseries_test:", &TimeSeriesOptions{Storage: storage, Retention: time.Hour})
+
+		var samples []Sample
+		for i := 0; i < 6; i++ {
+			at := base.Add(time.Duration(i) * 20 * time.Minute)
+			samples = append(samples,
+				Sample{"a", Point{at, float64(i)}},
+				Sample{"b", Point{at, float64(-i)}})
+		}
+		if err := ts.AddMulti(samples); err != nil {
+			t.Fatalf("AddMulti failed, err:%v\n", err)
+		}
+
+		points, err := ts.Range("a", base.Add(20*time.Minute), base.Add(60*time.Minute))
+		expected := []Point{{base.Add(20 * time.Minute), 1}, {base.Add(40 * time.Minute), 2}, {base.Add(60 * time.Minute), 3}}
+		if err != nil || !reflect.DeepEqual(points, expected) {
+			t.Fatalf("Range failed, expected:%v, got:%v, err:%v\n", expected, points, err)
+		}
+
+		points, err = ts.Downsample("b", base, base.Add(2*time.Hour), time.Hour, AggMin)
+		if err != nil || len(points) != 3 || points[1].Value != -4 {
+			t.Fatalf("Downsample failed, got:%v, err:%v\n", points, err)
+		}
+
+		if err := ts.Trim("a", "b"); err != nil {
+			t.Fatalf("Trim failed, err:%v\n", err)
+		}
+		points, err = ts.Range("a", base, base.Add(2*time.Hour))
+		if err != nil || len(points) == 0 || len(points) == 6 || points[0].Time.Before(base.Add(30*time.Minute)) {
+			t.Fatalf("Trim failed, got:%v, err:%v\n", points, err)
+		}
+
+		ts.opts.Retention = time.Nanosecond
+		ts.Trim("a", "b")
+	}
+}
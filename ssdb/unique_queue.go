@@ -0,0 +1,194 @@
+package ssdb
+
+import (
+	"errors"
+)
+
+// UniqueQueue is a queue dropping the values already in it, like the urls to crawl.
+// The values are kept in the queue name, and as the keys of the hashmap name+":members",
+// so a value is pushed only if hset adds it, and it's removed from the hashmap once popped.
+// The values must fit in the length of hashmap keys.
+// Notice: ssdb has no transactions, a client crashing between the queue and the hashmap
+// leaves a value in only one of them, Repair reconciles them.
+type UniqueQueue struct {
+	r       Runner
+	name    string
+	members string
+}
+
+// NewUniqueQueue returns a UniqueQueue on a Client or a Pool.
+func NewUniqueQueue(r Runner, name string) *UniqueQueue {
+	return &UniqueQueue{r: r, name: name, members: name + ":members"}
+}
+
+// Push adds the values not in the queue to its back, in order, and returns the number added.
+// The values are added to the hashmap by pipelined hset in batches, and removed from it again
+// if the batch fails to be pushed to the queue.
+func (q *UniqueQueue) Push(values ...string) (int64, error) {
+	var n int64
+	err := q.r.Run(func(c *Client) error {
+		for len(values) > 0 {
+			batch := values[:min(len(values), 1000)]
+			values = values[len(batch):]
+
+			cmds := make([][]interface{}, len(batch))
+			for i, v := range batch {
+				cmds[i] = []interface{}{"hset", q.members, v, 1}
+			}
+			resps, err := c.pipeline(cmds)
+			if err != nil {
+				return err
+			}
+			added := make([]interface{}, 0, len(batch))
+			for i, resp := range resps {
+				if len(resp) < 2 || resp[0] != "ok" {
+					// the values added by the batch are not queued.
+					q.rollback(c, added)
+					if len(resp) == 0 {
+						return errors.New("no response received")
+					}
+					return errors.New(resp[0])
+				}
+				if resp[1] == "1" {
+					added = append(added, batch[i])
+				}
+			}
+			if len(added) == 0 {
+				continue
+			}
+
+			if _, err := c.QpushBack(q.name, added...); err != nil {
+				q.rollback(c, added)
+				return err
+			}
+			n += int64(len(added))
+		}
+		return nil
+	})
+	return n, err
+}
+
+// rollback removes the values from the hashmap, the values left on failure are reconciled by Repair.
+func (q *UniqueQueue) rollback(c *Client, values []interface{}) {
+	if len(values) > 0 {
+		c.MultiHdel(q.members, values...)
+	}
+}
+
+// Pop removes and returns the value at the front, ok is false if the queue is empty.
+func (q *UniqueQueue) Pop() (value string, ok bool, err error) {
+	values, err := q.PopN(1)
+	if err != nil || len(values) == 0 {
+		return "", false, err
+	}
+	return values[0], true, nil
+}
+
+// PopN removes and returns at most n values at the front, they can be pushed again after.
+func (q *UniqueQueue) PopN(n int) ([]string, error) {
+	var values []string
+	err := q.r.Run(func(c *Client) error {
+		var err error
+		values, err = c.unpackValues(c.doReturnRange("qpop_front", q.name, n))
+		if err != nil && err.Error() == "not_found" {
+			// qpop_front of one item replies like qfront if the queue is empty.
+			return nil
+		}
+		if err != nil || len(values) == 0 {
+			return err
+		}
+		_, err = c.MultiHdel(q.members, values)
+		return err
+	})
+	return values, err
+}
+
+// Contains reports whether value is in the queue.
+func (q *UniqueQueue) Contains(value string) (bool, error) {
+	var n int64
+	err := q.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Hexists(q.members, value)
+		return err
+	})
+	return n == 1, err
+}
+
+// Size returns the number of values in the queue.
+func (q *UniqueQueue) Size() (int64, error) {
+	var n int64
+	err := q.r.Run(func(c *Client) error {
+		var err error
+		n, err = c.Qsize(q.name)
+		return err
+	})
+	return n, err
+}
+
+// Clear deletes all the values.
+func (q *UniqueQueue) Clear() error {
+	return q.r.Run(func(c *Client) error {
+		if _, err := c.Qclear(q.name); err != nil {
+			return err
+		}
+		_, err := c.Hclear(q.members)
+		return err
+	})
+}
+
+// Repair adds the values in the queue missing in the hashmap, and removes the values in the
+// hashmap missing in the queue, so they can be pushed again. It returns the numbers of both.
+// The values of the queue are loaded in memory, and it should run while no one pushes or pops,
+// or the values being pushed may be removed from the hashmap.
+func (q *UniqueQueue) Repair() (added, removed int64, err error) {
+	err = q.r.Run(func(c *Client) error {
+		queued := map[string]bool{}
+		it := c.QrangeIter(q.name, 0, 1000)
+		for it.Next() {
+			queued[it.Key()] = true
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		var stale []string
+		hit := c.HkeysIter(q.members, "", "", 1000)
+		for hit.Next() {
+			if queued[hit.Key()] {
+				delete(queued, hit.Key())
+			} else {
+				stale = append(stale, hit.Key())
+			}
+		}
+		if err := hit.Err(); err != nil {
+			return err
+		}
+
+		for len(stale) > 0 {
+			n := min(len(stale), 1000)
+			if _, err := c.MultiHdel(q.members, stale[:n]); err != nil {
+				return err
+			}
+			removed += int64(n)
+			stale = stale[n:]
+		}
+		// the values left are queued but not members.
+		kvs := make([]interface{}, 0, 2000)
+		for v := range queued {
+			kvs = append(kvs, v, 1)
+			if len(kvs) == cap(kvs) {
+				if _, err := c.MultiHset(q.members, kvs...); err != nil {
+					return err
+				}
+				kvs = kvs[:0]
+			}
+			added++
+		}
+		if len(kvs) > 0 {
+			_, err := c.MultiHset(q.members, kvs...)
+			return err
+		}
+		return nil
+	})
+	return added, removed, err
+}
@@ -0,0 +1,103 @@
+package ssdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUniqueQueue(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewUniqueQueue(p, "unique_queue_test")
+	defer q.Clear()
+
+	n, err := q.Push("a", "b", "a", "c")
+	if err != nil || n != 3 {
+		t.Fatalf("Push failed, expected:3, got:%v, err:%v\n", n, err)
+	}
+	n, err = q.Push("b", "d")
+	if err != nil || n != 1 {
+		t.Fatalf("Push failed, expected:1, got:%v, err:%v\n", n, err)
+	}
+	size, err := q.Size()
+	if err != nil || size != 4 {
+		t.Fatalf("Size failed, expected:4, got:%v, err:%v\n", size, err)
+	}
+
+	v, ok, err := q.Pop()
+	if err != nil || !ok || v != "a" {
+		t.Fatalf("Pop failed, expected:a, got:%v, err:%v\n", v, err)
+	}
+	ok, err = q.Contains("a")
+	if err != nil || ok {
+		t.Fatalf("Contains failed, expected:false, got:%v, err:%v\n", ok, err)
+	}
+	if n, err = q.Push("a"); err != nil || n != 1 {
+		t.Fatalf("Push failed, expected:1, got:%v, err:%v\n", n, err)
+	}
+
+	// a value popped by a crashed client, and a value pushed to the queue only.
+	c := p.Get()
+	_, err = c.Hset("unique_queue_test:members", "lost", 1)
+	if err == nil {
+		_, err = c.QpushBack("unique_queue_test", "raw")
+	}
+	p.Release(c)
+	if err != nil {
+		t.Fatalf("Hset failed, err:%v\n", err)
+	}
+	added, removed, err := q.Repair()
+	if err != nil || added != 1 || removed != 1 {
+		t.Fatalf("Repair failed, expected:1 1, got:%v %v, err:%v\n", added, removed, err)
+	}
+	if n, err = q.Push("raw", "lost"); err != nil || n != 1 {
+		t.Fatalf("Push failed, expected:1, got:%v, err:%v\n", n, err)
+	}
+
+	values, err := q.PopN(10)
+	expected := []string{"b", "c", "d", "a", "raw", "lost"}
+	if err != nil || !reflect.DeepEqual(values, expected) {
+		t.Fatalf("PopN failed, expected:%v, got:%v, err:%v\n", expected, values, err)
+	}
+	if _, ok, err = q.Pop(); err != nil || ok {
+		t.Fatalf("Pop failed, expected empty, err:%v\n", err)
+	}
+}
+
+func TestUniqueQueuePushRollback(t *testing.T) {
+	s, c := newRecordingServer(t, "qpush_back")
+	defer s.ln.Close()
+	defer c.Close()
+
+	q := NewUniqueQueue(c, "q")
+	if _, err := q.Push("a", "b"); err == nil {
+		t.Fatalf("Push failed, expected error\n")
+	}
+	var cmds []string
+	for _, req := range s.requests() {
+		cmds = append(cmds, strings.Join(req, " "))
+	}
+	expected := []string{"hset q:members a 1", "hset q:members b 1", "qpush_back q a b", "multi_hdel q:members a b"}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Fatalf("Push failed, expected:%q, got:%q\n", expected, cmds)
+	}
+}
+
+func TestUniqueQueuePopEmpty(t *testing.T) {
+	s, c := newRecordingServer(t)
+	defer s.ln.Close()
+	defer c.Close()
+
+	s.reply("qpop_front", "not_found")
+	q := NewUniqueQueue(c, "q")
+	if value, ok, err := q.Pop(); err != nil || ok || value != "" {
+		t.Fatalf("Pop failed, expected empty, got:%v %v, err:%v\n", value, ok, err)
+	}
+	if reqs := s.requests(); len(reqs) != 1 {
+		t.Fatalf("Pop failed, expected qpop_front only, got:%q\n", reqs)
+	}
+}
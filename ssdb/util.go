@@ -88,3 +88,19 @@ func formatAtom(v reflect.Value) (string, error) {
 		return "", fmt.Errorf("unsupported data type %v", v.Kind())
 	}
 }
+
+// atomBytes formats a value as formatData does, to the bytes sent to server.
+func atomBytes(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		s, err := formatAtom(reflect.ValueOf(v))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}
+}
@@ -0,0 +1,284 @@
+package ssdb
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrJobNotInFlight is returned by Ack and Nack if the job is not in flight any more,
+// because its visibility deadline passed and it was redelivered or dead-lettered.
+var ErrJobNotInFlight = errors.New("job not in flight")
+
+// WorkQueueOptions configures a WorkQueue.
+type WorkQueueOptions struct {
+	// Visibility is how long a popped job stays invisible to other workers before it's redelivered,
+	// 30 seconds by default.
+	Visibility time.Duration
+	// MaxAttempts is the number of pops a job is allowed, after which it's moved to the dead-letter
+	// queue instead of redelivered, 0 for no limit.
+	MaxAttempts int64
+	// DeadLetter is the name of the queue receiving the bodies of dead jobs, name+":dead" by default.
+	DeadLetter string
+}
+
+// WorkQueue is a reliable queue of jobs with acknowledgements, delivering each job at least once.
+// The ids of ready jobs are kept in the queue name, and popped jobs are moved to the zset
+// name+":inflight" scored by their visibility deadlines in milliseconds, until they are acked.
+// The bodies and attempts of jobs are kept in the hashmaps name+":jobs" and name+":attempts".
+// Notice: ssdb has no transactions, a job popped by a worker crashing right before it's recorded
+// in flight is lost.
+type WorkQueue struct {
+	r           Runner
+	name        string
+	inflight    string
+	jobs        string
+	attempts    string
+	deadLetter  string
+	visibility  time.Duration
+	maxAttempts int64
+}
+
+// Job is a job popped from a WorkQueue.
+type Job struct {
+	ID   string
+	Body string
+	// Attempts is the number of times the job has been popped, including this one.
+	Attempts int64
+	// Deadline is when the job is redelivered if not acked.
+	Deadline time.Time
+}
+
+// WorkQueueStats is the metrics of a WorkQueue.
+type WorkQueueStats struct {
+	// Depth is the number of jobs ready.
+	Depth int64
+	// InFlight is the number of jobs popped and not acked yet.
+	InFlight int64
+	// Dead is the number of jobs in the dead-letter queue.
+	Dead int64
+}
+
+// NewWorkQueue returns a WorkQueue on a Client or a Pool, opts may be nil for the defaults.
+func NewWorkQueue(r Runner, name string, opts *WorkQueueOptions) *WorkQueue {
+	q := &WorkQueue{
+		r:          r,
+		name:       name,
+		inflight:   name + ":inflight",
+		jobs:       name + ":jobs",
+		attempts:   name + ":attempts",
+		deadLetter: name + ":dead",
+		visibility: 30 * time.Second,
+	}
+	if opts != nil {
+		if opts.Visibility > 0 {
+			q.visibility = opts.Visibility
+		}
+		if opts.DeadLetter != "" {
+			q.deadLetter = opts.DeadLetter
+		}
+		q.maxAttempts = opts.MaxAttempts
+	}
+	return q
+}
+
+// Push adds a job to the back of the queue, and returns its id.
+func (q *WorkQueue) Push(body interface{}) (string, error) {
+	id, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	err = q.r.Run(func(c *Client) error {
+		_, err := c.Hset(q.jobs, id, body)
+		if err != nil {
+			return err
+		}
+		_, err = c.QpushBack(q.name, id)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Pop takes the job at the front of the queue, and hides it from other workers until
+// the visibility deadline. It returns nil if the queue is empty.
+// The jobs with passed deadlines are redelivered first.
+func (q *WorkQueue) Pop() (*Job, error) {
+	if _, err := q.Redeliver(); err != nil {
+		return nil, err
+	}
+
+	var job *Job
+	err := q.r.Run(func(c *Client) error {
+		for job == nil {
+			// qpop_front of one item replies like qfront, not_found if the queue is empty.
+			ids, err := c.unpackValues(c.doReturnRange("qpop_front", q.name, 1))
+			if err != nil && err.Error() == "not_found" {
+				return nil
+			}
+			if err != nil || len(ids) == 0 {
+				return err
+			}
+			id := ids[0]
+
+			deadline := time.Now().Add(q.visibility)
+			_, err = c.Zset(q.inflight, id, deadline.UnixMilli())
+			if err != nil {
+				return err
+			}
+			body, err := c.Hget(q.jobs, id)
+			if err != nil {
+				if err.Error() != "not_found" {
+					return err
+				}
+				// acked by a late worker after it was redelivered, drop it.
+				if _, err = c.Zdel(q.inflight, id); err != nil {
+					return err
+				}
+				if err = q.drop(c, id); err != nil {
+					return err
+				}
+				continue
+			}
+			n, err := c.Hincr(q.attempts, id, 1)
+			if err != nil {
+				return err
+			}
+			job = &Job{ID: id, Body: body, Attempts: n, Deadline: deadline}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Ack acknowledges the job is done and deletes it.
+// ErrJobNotInFlight is returned if the job was redelivered meanwhile, it's deleted anyway.
+func (q *WorkQueue) Ack(job *Job) error {
+	return q.r.Run(func(c *Client) error {
+		n, err := c.Zdel(q.inflight, job.ID)
+		if err != nil {
+			return err
+		}
+		if err = q.drop(c, job.ID); err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrJobNotInFlight
+		}
+		return nil
+	})
+}
+
+// Nack gives the job up, it's returned to the back of the queue, or moved to the dead-letter queue
+// if it has run out of attempts.
+func (q *WorkQueue) Nack(job *Job) error {
+	return q.r.Run(func(c *Client) error {
+		n, err := c.Zdel(q.inflight, job.ID)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrJobNotInFlight
+		}
+		return q.requeue(c, job.ID)
+	})
+}
+
+// Redeliver returns the jobs with passed visibility deadlines to the queue, or moves them to
+// the dead-letter queue if they have run out of attempts, and returns the number of jobs handled.
+// It's called by Pop, and is safe to run by many workers concurrently.
+func (q *WorkQueue) Redeliver() (int, error) {
+	var count int
+	err := q.r.Run(func(c *Client) error {
+		now := time.Now().UnixMilli()
+		for {
+			members, err := c.ZscanByScore(q.inflight, "", "-inf", strconv.FormatInt(now, 10), 100)
+			if err != nil {
+				if err.Error() == "no data found" {
+					return nil
+				}
+				return err
+			}
+			for _, m := range members {
+				// only the worker deleting it handles it.
+				n, err := c.Zdel(q.inflight, m.Key)
+				if err != nil {
+					return err
+				}
+				if n == 0 {
+					continue
+				}
+				if err = q.requeue(c, m.Key); err != nil {
+					return err
+				}
+				count++
+			}
+			if len(members) < 100 {
+				return nil
+			}
+		}
+	})
+	return count, err
+}
+
+// Stats returns the metrics of the queue.
+func (q *WorkQueue) Stats() (*WorkQueueStats, error) {
+	stats := &WorkQueueStats{}
+	err := q.r.Run(func(c *Client) error {
+		var err error
+		if stats.Depth, err = c.Qsize(q.name); err != nil {
+			return err
+		}
+		if stats.InFlight, err = c.Zsize(q.inflight); err != nil {
+			return err
+		}
+		stats.Dead, err = c.Qsize(q.deadLetter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// requeue returns the job removed from flight to the queue, or moves it to the dead-letter queue.
+func (q *WorkQueue) requeue(c *Client, id string) error {
+	if q.maxAttempts > 0 {
+		s, err := c.Hget(q.attempts, id)
+		if err != nil && err.Error() != "not_found" {
+			return err
+		}
+		n, _ := strconv.ParseInt(s, 10, 64)
+		if n >= q.maxAttempts {
+			body, err := c.Hget(q.jobs, id)
+			if err != nil {
+				if err.Error() == "not_found" {
+					return q.drop(c, id)
+				}
+				return err
+			}
+			_, err = c.QpushBack(q.deadLetter, body)
+			if err != nil {
+				return err
+			}
+			return q.drop(c, id)
+		}
+	}
+	_, err := c.QpushBack(q.name, id)
+	return err
+}
+
+// drop deletes the body and attempts of the job.
+func (q *WorkQueue) drop(c *Client, id string) error {
+	_, err := c.Hdel(q.jobs, id)
+	if err != nil {
+		return err
+	}
+	_, err = c.Hdel(q.attempts, id)
+	return err
+}
@@ -0,0 +1,76 @@
+package ssdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkQueue(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "workqueue_test"
+	q := NewWorkQueue(p, name, &WorkQueueOptions{Visibility: time.Second, MaxAttempts: 2})
+	defer func() {
+		c := p.Get()
+		c.Qclear(name)
+		c.Qclear(name + ":dead")
+		c.Zclear(name + ":inflight")
+		c.Hclear(name + ":jobs")
+		c.Hclear(name + ":attempts")
+		p.Release(c)
+	}()
+
+	_, err = q.Push("a")
+	if err != nil {
+		t.Fatalf("Push failed, err:%v\n", err)
+	}
+	_, err = q.Push("b")
+	if err != nil {
+		t.Fatalf("Push failed, err:%v\n", err)
+	}
+
+	job, err := q.Pop()
+	if err != nil || job == nil || job.Body != "a" || job.Attempts != 1 {
+		t.Fatalf("Pop failed, got:%+v, err:%v\n", job, err)
+	}
+	err = q.Ack(job)
+	if err != nil {
+		t.Fatalf("Ack failed, err:%v\n", err)
+	}
+	err = q.Ack(job)
+	if err != ErrJobNotInFlight {
+		t.Fatalf("Ack failed, expected:%v, got:%v\n", ErrJobNotInFlight, err)
+	}
+
+	// not acked, redelivered after the visibility deadline.
+	job, err = q.Pop()
+	if err != nil || job == nil || job.Body != "b" {
+		t.Fatalf("Pop failed, got:%+v, err:%v\n", job, err)
+	}
+	stats, err := q.Stats()
+	if err != nil || stats.Depth != 0 || stats.InFlight != 1 {
+		t.Fatalf("Stats failed, got:%+v, err:%v\n", stats, err)
+	}
+	job, err = q.Pop()
+	if err != nil || job != nil {
+		t.Fatalf("Pop failed, expected nil, got:%+v, err:%v\n", job, err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	job, err = q.Pop()
+	if err != nil || job == nil || job.Body != "b" || job.Attempts != 2 {
+		t.Fatalf("Pop failed, got:%+v, err:%v\n", job, err)
+	}
+
+	// out of attempts, dead-lettered.
+	err = q.Nack(job)
+	if err != nil {
+		t.Fatalf("Nack failed, err:%v\n", err)
+	}
+	stats, err = q.Stats()
+	if err != nil || stats.Depth != 0 || stats.InFlight != 0 || stats.Dead != 1 {
+		t.Fatalf("Stats failed, got:%+v, err:%v\n", stats, err)
+	}
+}
@@ -0,0 +1,149 @@
+package ssdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ZMember is a key-score pair of a zset.
+type ZMember struct {
+	Key   string
+	Score int64
+}
+
+// toMembers converts the key-score pairs to ZMembers, parsing the scores.
+func toMembers(m OrderedMap) ([]ZMember, error) {
+	members := make([]ZMember, 0, m.Length())
+	for i := 0; i < m.Length(); i++ {
+		k, v := m.Index(i)
+		score, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad score %q of key %q", v, k)
+		}
+		members = append(members, ZMember{Key: k, Score: score})
+	}
+	return members, nil
+}
+
+func membersOf(m OrderedMap, err error) ([]ZMember, error) {
+	if err != nil {
+		return nil, err
+	}
+	return toMembers(m)
+}
+
+// ZscanMembers works likely Zscan, but returns typed scores.
+func (c *Client) ZscanMembers(name, keyStart string, scoreStart, scoreEnd int64, limit int) ([]ZMember, error) {
+	return membersOf(c.Zscan(name, keyStart, scoreStart, scoreEnd, limit))
+}
+
+// ZrscanMembers works likely Zrscan, but returns typed scores.
+func (c *Client) ZrscanMembers(name, keyStart string, scoreStart, scoreEnd int64, limit int) ([]ZMember, error) {
+	return membersOf(c.Zrscan(name, keyStart, scoreStart, scoreEnd, limit))
+}
+
+// ZrangeMembers works likely Zrange, but returns typed scores.
+func (c *Client) ZrangeMembers(name string, offset, limit int) ([]ZMember, error) {
+	return membersOf(c.Zrange(name, offset, limit))
+}
+
+// ZrrangeMembers works likely Zrrange, but returns typed scores.
+func (c *Client) ZrrangeMembers(name string, offset, limit int) ([]ZMember, error) {
+	return membersOf(c.Zrrange(name, offset, limit))
+}
+
+// ZpopfrontMembers works likely Zpopfront, but returns typed scores.
+func (c *Client) ZpopfrontMembers(name string, limit int) ([]ZMember, error) {
+	return membersOf(c.Zpopfront(name, limit))
+}
+
+// ZpopbackMembers works likely Zpopback, but returns typed scores.
+func (c *Client) ZpopbackMembers(name string, limit int) ([]ZMember, error) {
+	return membersOf(c.Zpopback(name, limit))
+}
+
+// MultiZgetScores works likely MultiZget, but returns a key-score map,
+// the keys not existed are absent.
+func (c *Client) MultiZgetScores(name string, keys ...interface{}) (map[string]int64, error) {
+	return c.doReturnIntMap("multi_zget", name, keys)
+}
+
+// scoreBound converts a score bound to the form the server accepts.
+// The bound could be an integer, or "-inf", "+inf", "inf" and empty string. low tells whether
+// it's the lower bound of the range. Empty string, "-inf" as the lower bound, and "+inf" or "inf"
+// as the upper bound, are no limit. The other infinite bounds make the range empty.
+func scoreBound(s string, low bool) (bound interface{}, empty bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return "", false, nil
+	case "-inf":
+		return "", !low, nil
+	case "+inf", "inf":
+		return "", low, nil
+	}
+	score, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("bad score bound %q", s)
+	}
+	return score, false, nil
+}
+
+// scoreBounds converts the bounds of a range from start to end, which are descending if reverse.
+// empty is true if the range is empty because of an infinite bound.
+func scoreBounds(start, end string, reverse bool) (s, e interface{}, empty bool, err error) {
+	s, emptyStart, err := scoreBound(start, !reverse)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	e, emptyEnd, err := scoreBound(end, reverse)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return s, e, emptyStart || emptyEnd, nil
+}
+
+// ZscanByScore works likely ZscanMembers, but the score bounds could be "-inf" or "+inf" for no limit.
+func (c *Client) ZscanByScore(name, keyStart string, scoreStart, scoreEnd string, limit int) ([]ZMember, error) {
+	s, e, empty, err := scoreBounds(scoreStart, scoreEnd, false)
+	if err != nil || empty {
+		return nil, err
+	}
+	return membersOf(c.doReturnStringMap("zscan", name, keyStart, s, e, limit))
+}
+
+// ZrscanByScore works likely ZrscanMembers, but the score bounds could be "+inf" or "-inf" for no limit.
+func (c *Client) ZrscanByScore(name, keyStart string, scoreStart, scoreEnd string, limit int) ([]ZMember, error) {
+	s, e, empty, err := scoreBounds(scoreStart, scoreEnd, true)
+	if err != nil || empty {
+		return nil, err
+	}
+	return membersOf(c.doReturnStringMap("zrscan", name, keyStart, s, e, limit))
+}
+
+// ZcountByScore works likely Zcount, but the score bounds could be "-inf" or "+inf" for no limit.
+func (c *Client) ZcountByScore(name string, start, end string) (int64, error) {
+	s, e, empty, err := scoreBounds(start, end, false)
+	if err != nil || empty {
+		return 0, err
+	}
+	return c.doReturnInt("zcount", name, s, e)
+}
+
+// ZsumByScore works likely Zsum, but the score bounds could be "-inf" or "+inf" for no limit.
+func (c *Client) ZsumByScore(name string, start, end string) (int64, error) {
+	s, e, empty, err := scoreBounds(start, end, false)
+	if err != nil || empty {
+		return 0, err
+	}
+	return c.doReturnInt("zsum", name, s, e)
+}
+
+// ZremByScore works likely Zremrangebyscore, but the score bounds could be "-inf" or "+inf" for no limit.
+func (c *Client) ZremByScore(name string, start, end string) (int64, error) {
+	s, e, empty, err := scoreBounds(start, end, false)
+	if err != nil || empty {
+		return 0, err
+	}
+	return c.doReturnInt("zremrangebyscore", name, s, e)
+}
@@ -0,0 +1,89 @@
+package ssdb
+
+import (
+	"testing"
+)
+
+func TestScoreBound(t *testing.T) {
+	for _, s := range []string{"", "-inf", " -INF "} {
+		b, empty, err := scoreBound(s, true)
+		if err != nil {
+			t.Fatalf("scoreBound(%q) failed, err:%v\n", s, err)
+		}
+		if b != "" || empty {
+			t.Fatalf("scoreBound(%q) failed, expected no limit, got:%v\n", s, b)
+		}
+	}
+	for _, s := range []string{"", "+inf", "inf"} {
+		b, empty, err := scoreBound(s, false)
+		if err != nil {
+			t.Fatalf("scoreBound(%q) failed, err:%v\n", s, err)
+		}
+		if b != "" || empty {
+			t.Fatalf("scoreBound(%q) failed, expected no limit, got:%v\n", s, b)
+		}
+	}
+
+	b, _, err := scoreBound("-42", true)
+	if err != nil {
+		t.Fatalf("scoreBound failed, err:%v\n", err)
+	}
+	if b != int64(-42) {
+		t.Fatalf("scoreBound failed, expected:%v, got:%v\n", -42, b)
+	}
+
+	_, _, err = scoreBound("x", true)
+	if err == nil {
+		t.Fatalf("scoreBound failed, expected error for bad bound\n")
+	}
+}
+
+func TestScoreBounds(t *testing.T) {
+	tests := []struct {
+		start, end string
+		reverse    bool
+		empty      bool
+	}{
+		{"-inf", "+inf", false, false},
+		{"+inf", "-inf", false, true},
+		{"inf", "", false, true},
+		{"", "-inf", false, true},
+		{"1", "+inf", false, false},
+		{"+inf", "-inf", true, false},
+		{"-inf", "+inf", true, true},
+		{"", "inf", true, true},
+		{"-inf", "", true, true},
+		{"+inf", "1", true, false},
+	}
+	for _, tt := range tests {
+		_, _, empty, err := scoreBounds(tt.start, tt.end, tt.reverse)
+		if err != nil {
+			t.Fatalf("scoreBounds(%q, %q, %v) failed, err:%v\n", tt.start, tt.end, tt.reverse, err)
+		}
+		if empty != tt.empty {
+			t.Fatalf("scoreBounds(%q, %q, %v) failed, expected empty:%v, got:%v\n",
+				tt.start, tt.end, tt.reverse, tt.empty, empty)
+		}
+	}
+}
+
+func TestToMembers(t *testing.T) {
+	members, err := toMembers(newMap([]string{"a", "3", "b", "-2"}))
+	if err != nil {
+		t.Fatalf("toMembers failed, err:%v\n", err)
+	}
+	expected := []ZMember{{"a", 3}, {"b", -2}}
+	if len(members) != len(expected) {
+		t.Fatalf("toMembers failed, expected:%v, got:%v\n", expected, members)
+	}
+	for i := range expected {
+		if members[i] != expected[i] {
+			t.Fatalf("toMembers failed, expected:%v, got:%v\n", expected, members)
+		}
+	}
+
+	_, err = toMembers(newMap([]string{"a", "x"}))
+	if err == nil {
+		t.Fatalf("toMembers failed, expected error for bad score\n")
+	}
+}
@@ -0,0 +1,324 @@
+package ssdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ZAggregate is how the scores of a member in many zsets are combined.
+type ZAggregate int
+
+// The aggregations of the weighted scores.
+const (
+	ZSum ZAggregate = iota
+	ZMin
+	ZMax
+)
+
+// ZSetOpOptions configures ZUnion, ZInter and ZDiff.
+type ZSetOpOptions struct {
+	// Weights are multiplied with the scores of the zsets in order, 1 for the zsets without weights.
+	Weights []int64
+	// Aggregate is ZSum by default.
+	Aggregate ZAggregate
+	// Batch is the number of members scanned and looked up at once, 100 by default.
+	Batch int
+}
+
+// zsetOp streams the members of zsets in batches with their weighted scores.
+// Zsets are scanned by score, not by key, so they can't be merged by key. Instead a zset is
+// scanned batch by batch, and the members of every batch are looked up in the other zsets
+// by multi_zget, so the memory used is bounded by the batch size.
+type zsetOp struct {
+	c       *Client
+	names   []string
+	weights []int64
+	agg     ZAggregate
+	batch   int
+}
+
+func (c *Client) newZsetOp(names []string, opts *ZSetOpOptions) (*zsetOp, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no zsets given")
+	}
+	op := &zsetOp{c: c, names: names, batch: defaultBatch}
+	if opts != nil {
+		if len(opts.Weights) > len(names) {
+			return nil, fmt.Errorf("%d weights given for %d zsets", len(opts.Weights), len(names))
+		}
+		op.weights = opts.Weights
+		op.agg = opts.Aggregate
+		if opts.Batch > 0 {
+			op.batch = opts.Batch
+		}
+	}
+	return op, nil
+}
+
+func (op *zsetOp) weight(i int) int64 {
+	if i < len(op.weights) {
+		return op.weights[i]
+	}
+	return 1
+}
+
+// combine aggregates the weighted score of zset i into the score so far,
+// it returns an error if the weighted score or the sum overflows.
+func (op *zsetOp) combine(score int64, first bool, i int, s int64) (int64, error) {
+	w := op.weight(i)
+	if w != 0 && ((s*w)/w != s || (s == math.MinInt64 && w == -1)) {
+		return 0, fmt.Errorf("score %d of zset %q weighted by %d overflows", s, op.names[i], w)
+	}
+	s *= w
+	switch {
+	case first:
+		return s, nil
+	case op.agg == ZMin:
+		return min(score, s), nil
+	case op.agg == ZMax:
+		return max(score, s), nil
+	}
+	if (s > 0 && score > math.MaxInt64-s) || (s < 0 && score < math.MinInt64-s) {
+		return 0, fmt.Errorf("sum of scores in zset %q overflows", op.names[i])
+	}
+	return score + s, nil
+}
+
+// scan calls f with the members of zset i, batch by batch.
+func (op *zsetOp) scan(i int, f func(members []ZMember) error) error {
+	it := op.c.zscanIter(op.names[i], "", "", "", op.batch)
+	members := make([]ZMember, 0, op.batch)
+	for it.Next() {
+		score, err := strconv.ParseInt(it.Value(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad score %q of key %q", it.Value(), it.Key())
+		}
+		members = append(members, ZMember{Key: it.Key(), Score: score})
+		if len(members) == op.batch {
+			if err := f(members); err != nil {
+				return err
+			}
+			members = members[:0]
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if len(members) > 0 {
+		return f(members)
+	}
+	return nil
+}
+
+// lookup returns the scores of the members in zset i, the members not in the zset are absent.
+func (op *zsetOp) lookup(i int, members []ZMember) (map[string]int64, error) {
+	keys := make([]string, len(members))
+	for j, m := range members {
+		keys[j] = m.Key
+	}
+	resp, err := op.c.doReturnRange("multi_zget", op.names[i], keys)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]int64, len(resp)/2)
+	for j := 0; j+1 < len(resp); j += 2 {
+		score, err := strconv.ParseInt(resp[j+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad score %q of key %q", resp[j+1], resp[j])
+		}
+		scores[resp[j]] = score
+	}
+	return scores, nil
+}
+
+// ZUnion calls f with the members in any of the zsets, in batches,
+// their scores are the weighted scores in the zsets having them, aggregated.
+func (c *Client) ZUnion(names []string, opts *ZSetOpOptions, f func(members []ZMember) error) error {
+	op, err := c.newZsetOp(names, opts)
+	if err != nil {
+		return err
+	}
+	for i := range names {
+		err := op.scan(i, func(members []ZMember) error {
+			var out []ZMember
+			found := make([]map[string]int64, len(names))
+			for j := range names {
+				if j == i {
+					continue
+				}
+				var err error
+				if found[j], err = op.lookup(j, members); err != nil {
+					return err
+				}
+			}
+		next:
+			for _, m := range members {
+				// emitted by the zsets before.
+				for j := 0; j < i; j++ {
+					if _, ok := found[j][m.Key]; ok {
+						continue next
+					}
+				}
+				score, err := op.combine(0, true, i, m.Score)
+				if err != nil {
+					return err
+				}
+				for j := i + 1; j < len(names); j++ {
+					if s, ok := found[j][m.Key]; ok {
+						if score, err = op.combine(score, false, j, s); err != nil {
+							return err
+						}
+					}
+				}
+				out = append(out, ZMember{Key: m.Key, Score: score})
+			}
+			if len(out) == 0 {
+				return nil
+			}
+			return f(out)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ZInter calls f with the members in all the zsets, in batches,
+// their scores are the weighted scores in the zsets, aggregated.
+// The smallest zset is scanned.
+func (c *Client) ZInter(names []string, opts *ZSetOpOptions, f func(members []ZMember) error) error {
+	op, err := c.newZsetOp(names, opts)
+	if err != nil {
+		return err
+	}
+	smallest, size := 0, int64(-1)
+	for i, name := range names {
+		n, err := c.Zsize(name)
+		if err != nil {
+			return err
+		}
+		if size < 0 || n < size {
+			smallest, size = i, n
+		}
+	}
+	if size == 0 {
+		return nil
+	}
+
+	return op.scan(smallest, func(members []ZMember) error {
+		found := make([]map[string]int64, len(names))
+		for j := range names {
+			if j == smallest {
+				continue
+			}
+			var err error
+			if found[j], err = op.lookup(j, members); err != nil {
+				return err
+			}
+		}
+
+		var out []ZMember
+	next:
+		for _, m := range members {
+			// aggregate in the order of names, like ZUnion.
+			var score int64
+			for j := range names {
+				s := m.Score
+				if j != smallest {
+					var ok bool
+					if s, ok = found[j][m.Key]; !ok {
+						continue next
+					}
+				}
+				var err error
+				if score, err = op.combine(score, j == 0, j, s); err != nil {
+					return err
+				}
+			}
+			out = append(out, ZMember{Key: m.Key, Score: score})
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return f(out)
+	})
+}
+
+// ZDiff calls f with the members of the first zset not in any of the others, in batches,
+// with their weighted scores in the first zset.
+func (c *Client) ZDiff(names []string, opts *ZSetOpOptions, f func(members []ZMember) error) error {
+	op, err := c.newZsetOp(names, opts)
+	if err != nil {
+		return err
+	}
+	return op.scan(0, func(members []ZMember) error {
+		excluded := map[string]bool{}
+		for j := 1; j < len(names); j++ {
+			found, err := op.lookup(j, members)
+			if err != nil {
+				return err
+			}
+			for key := range found {
+				excluded[key] = true
+			}
+		}
+
+		var out []ZMember
+		for _, m := range members {
+			if excluded[m.Key] {
+				continue
+			}
+			score, err := op.combine(0, true, 0, m.Score)
+			if err != nil {
+				return err
+			}
+			out = append(out, ZMember{Key: m.Key, Score: score})
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return f(out)
+	})
+}
+
+// ZUnionStore stores the result of ZUnion in the zset dest, replacing it, and returns its size.
+func (c *Client) ZUnionStore(dest string, names []string, opts *ZSetOpOptions) (int64, error) {
+	return c.zstore(dest, names, opts, c.ZUnion)
+}
+
+// ZInterStore stores the result of ZInter in the zset dest, replacing it, and returns its size.
+func (c *Client) ZInterStore(dest string, names []string, opts *ZSetOpOptions) (int64, error) {
+	return c.zstore(dest, names, opts, c.ZInter)
+}
+
+// ZDiffStore stores the result of ZDiff in the zset dest, replacing it, and returns its size.
+func (c *Client) ZDiffStore(dest string, names []string, opts *ZSetOpOptions) (int64, error) {
+	return c.zstore(dest, names, opts, c.ZDiff)
+}
+
+func (c *Client) zstore(dest string, names []string, opts *ZSetOpOptions,
+	op func(names []string, opts *ZSetOpOptions, f func(members []ZMember) error) error) (int64, error) {
+	for _, name := range names {
+		if name == dest {
+			return 0, errors.New("the destination is one of the zsets")
+		}
+	}
+	if _, err := c.Zclear(dest); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err := op(names, opts, func(members []ZMember) error {
+		kvs := make([]interface{}, 0, len(members)*2)
+		for _, m := range members {
+			kvs = append(kvs, m.Key, m.Score)
+		}
+		_, err := c.MultiZset(dest, kvs...)
+		n += int64(len(members))
+		return err
+	})
+	return n, err
+}
@@ -0,0 +1,96 @@
+package ssdb
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestZsetOps(t *testing.T) {
+	p, err := newPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := p.Get()
+	defer p.Release(c)
+
+	a, b, dest := "zsetops_test_a", "zsetops_test_b", "zsetops_test_dest"
+	defer func() {
+		c.Zclear(a)
+		c.Zclear(b)
+		c.Zclear(dest)
+	}()
+	c.MultiZset(a, "x", 1, "y", 2, "z", 3)
+	c.MultiZset(b, "y", 10, "z", 20, "w", 30)
+
+	collect := func(op func([]string, *ZSetOpOptions, func([]ZMember) error) error, opts *ZSetOpOptions) map[string]int64 {
+		res := map[string]int64{}
+		err := op([]string{a, b}, opts, func(members []ZMember) error {
+			for _, m := range members {
+				if _, ok := res[m.Key]; ok {
+					t.Fatalf("duplicate member %v\n", m.Key)
+				}
+				res[m.Key] = m.Score
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("zset operation failed, err:%v\n", err)
+		}
+		return res
+	}
+
+	opts := &ZSetOpOptions{Weights: []int64{2}, Batch: 2}
+	tests := []struct {
+		name     string
+		got      map[string]int64
+		expected map[string]int64
+	}{
+		{"ZUnion", collect(c.ZUnion, opts), map[string]int64{"x": 2, "y": 14, "z": 26, "w": 30}},
+		{"ZInter", collect(c.ZInter, &ZSetOpOptions{Aggregate: ZMax}), map[string]int64{"y": 10, "z": 20}},
+		{"ZDiff", collect(c.ZDiff, nil), map[string]int64{"x": 1}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.expected) {
+			t.Fatalf("%s failed, expected:%v, got:%v\n", tt.name, tt.expected, tt.got)
+		}
+	}
+
+	n, err := c.ZInterStore(dest, []string{a, b}, &ZSetOpOptions{Aggregate: ZMin})
+	if err != nil || n != 2 {
+		t.Fatalf("ZInterStore failed, expected:2, got:%v, err:%v\n", n, err)
+	}
+	members, err := c.ZrangeMembers(dest, 0, 10)
+	sort.Slice(members, func(i, j int) bool { return members[i].Key < members[j].Key })
+	expected := []ZMember{{"y", 2}, {"z", 3}}
+	if err != nil || !reflect.DeepEqual(members, expected) {
+		t.Fatalf("ZInterStore failed, expected:%v, got:%v, err:%v\n", expected, members, err)
+	}
+}
+
+func TestZsetCombine(t *testing.T) {
+	op := &zsetOp{names: []string{"a", "b"}, weights: []int64{2}}
+	score, err := op.combine(0, true, 0, 3)
+	if err != nil || score != 6 {
+		t.Fatalf("combine failed, expected:6, got:%v, err:%v\n", score, err)
+	}
+	score, err = op.combine(score, false, 1, 4)
+	if err != nil || score != 10 {
+		t.Fatalf("combine failed, expected:10, got:%v, err:%v\n", score, err)
+	}
+
+	if _, err = op.combine(0, true, 0, math.MaxInt64/2+1); err == nil {
+		t.Fatalf("combine failed, expected error for weighted score overflow\n")
+	}
+	if _, err = op.combine(math.MaxInt64, false, 1, 1); err == nil {
+		t.Fatalf("combine failed, expected error for sum overflow\n")
+	}
+	if _, err = op.combine(math.MinInt64, false, 1, -1); err == nil {
+		t.Fatalf("combine failed, expected error for sum overflow\n")
+	}
+	op.weights = []int64{-1}
+	if _, err = op.combine(0, true, 0, math.MinInt64); err == nil {
+		t.Fatalf("combine failed, expected error for weighted score overflow\n")
+	}
+}